@@ -19,16 +19,31 @@ func main() {
 
 	configFile := app.Arg("config-file", "population config file").Required().String()
 	outFile := app.Arg("output-file", "output file").Required().String()
+	newickFile := app.Flag("newick", "output file of the genealogy in Newick format").String()
+	treesPrefix := app.Flag("trees", "output prefix of the tree sequence tables").String()
 
 	kingpin.MustParse(app.Parse(os.Args[1:]))
 
 	pc := parsePopConfig(*configFile)
 	fmt.Println(pc)
 	pp := generatePopulation(pc)
+	pp.RecordMutations = *treesPrefix != ""
 
 	numGen := pc.Size * pc.Size * 10
 
 	evolve(pp, pc, numGen)
+	if pp.Transfer.Attempted > 0 {
+		fmt.Printf("Transfers accepted: %d of %d (%f)\n", pp.Transfer.Accepted, pp.Transfer.Attempted, pp.Transfer.AcceptanceRate())
+	}
+	if pc.Mutation.DFE.Rate > 0 {
+		l := pop.CalcMutationLoad(pp)
+		fmt.Printf("Mutations per genome: %f neutral, %f deleterious, %f beneficial\n", l.Neutral, l.Deleterious, l.Beneficial)
+		fmt.Printf("Least-loaded class: %d deleterious mutations (%f of genomes)\n", l.MinDeleterious, l.LeastLoaded)
+	}
+	if pc.Transfer.Insertion.Rate > 0 {
+		a := pop.CalcAccessory(pp)
+		fmt.Printf("Accessory sites per genome: %f (%f of genome, in %f of genomes)\n", a.MeanSize, a.MeanFraction, a.Carriers)
+	}
 
 	w, err := os.Create(*outFile)
 	if err != nil {
@@ -40,6 +55,48 @@ func main() {
 	if err := encoder.Encode(pp); err != nil {
 		panic(err)
 	}
+
+	if *newickFile != "" {
+		writeNewick(*newickFile, pp)
+	}
+
+	if *treesPrefix != "" {
+		writeTreeSequence(*treesPrefix, pp)
+	}
+}
+
+// writeNewick writes the genealogy of the population in Newick format.
+func writeNewick(file string, p *pop.Pop) {
+	w, err := os.Create(file)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer w.Close()
+
+	if _, err := fmt.Fprintln(w, pop.Newick(p)); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// writeTreeSequence writes the node, edge, site and mutation tables
+// of the population in the text format of tskit.
+func writeTreeSequence(prefix string, p *pop.Pop) {
+	pop.PruneLineages(p)
+	ts := pop.NewTreeSequence(p)
+
+	files := []*os.File{}
+	for _, name := range []string{"nodes", "edges", "sites", "mutations"} {
+		w, err := os.Create(prefix + "." + name + ".txt")
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer w.Close()
+		files = append(files, w)
+	}
+
+	if err := ts.WriteText(files[0], files[1], files[2], files[3]); err != nil {
+		log.Fatalln(err)
+	}
 }
 
 // parsePopConfig parse a JSON PopConfig
@@ -62,10 +119,17 @@ func generatePopulation(pc pop.Config) *pop.Pop {
 	r := rand.New(src)
 	p := pop.New()
 	g := pop.NewRandomPopGenerator(r, pc.Size, pc.Length, []byte(pc.Alphabet))
+	landscape, err := pop.NewLandscape([]byte(pc.Alphabet), pc.Length, pc.Landscape, src)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	g.Landscape = landscape
 	g.Operate(p)
 	return p
 }
 
 func evolve(p *pop.Pop, pc pop.Config, numGen int) {
-	simu.Moran([]*pop.Pop{p}, []pop.Config{pc}, numGen)
+	if err := simu.Moran([]*pop.Pop{p}, []pop.Config{pc}, numGen); err != nil {
+		log.Fatalln(err)
+	}
 }
@@ -31,6 +31,8 @@ type CalcRes struct {
 	Ct []float64
 	Cm []float64
 	Cr []float64
+	R2 []float64
+	DP []float64
 }
 
 func (c *cmdTwoPops) Run(args []string) {
@@ -51,6 +53,7 @@ func (c *cmdTwoPops) Run(args []string) {
 				p1 := pops[i]
 				ks, vd := pop.CalcKs(c.sampleSize, randomSrc, p1)
 				cm, ct, cr, cs := pop.CalcCov(c.sampleSize, c.maxl, randomSrc, p1)
+				ld := pop.CalcLD(c.sampleSize, c.maxl, 1, randomSrc, p1)
 				res := CalcRes{
 					ID: fmt.Sprintf("%d", i),
 					Ks: ks,
@@ -59,6 +62,8 @@ func (c *cmdTwoPops) Run(args []string) {
 					Ct: ct,
 					Cr: cr,
 					Cs: cs,
+					R2: ld.R2,
+					DP: ld.DPrime,
 				}
 				results.CalcResults = append(results.CalcResults, res)
 				for j := i + 1; j < len(c.popConfigs); j++ {
@@ -123,7 +128,9 @@ func (c *cmdTwoPops) RunOne(src rand.Source) []*pop.Pop {
 	for i := 0; i < len(pops); i++ {
 		pops[i] = newPop(c.popConfigs[i], &genome)
 	}
-	simu.Moran(pops, c.popConfigs, c.numGen)
+	if err := simu.Moran(pops, c.popConfigs, c.numGen); err != nil {
+		panic(err)
+	}
 
 	return pops
 }
@@ -152,6 +159,11 @@ func collect(resChan chan Results) map[string]*Collector {
 				corrRes.Type = "P2"
 				corrResults.Results = append(corrResults.Results, corrRes)
 			}
+			for i := range res.R2 {
+				corrResults.Results = append(corrResults.Results,
+					CorrResult{Lag: i, Mean: res.R2[i], Type: "R2"},
+					CorrResult{Lag: i, Mean: res.DP[i], Type: "DP"})
+			}
 			resMap[key].Add(corrResults)
 		}
 	}
@@ -176,28 +176,29 @@ type CalcRes struct {
 
 func calculateResults(pops []*pop.Pop, numGen int) []CalcRes {
 	calcResults := []CalcRes{}
+	src := rand.NewSource(time.Now().UnixNano())
 	for i := 0; i < len(pops); i++ {
 		p1 := pops[i]
-		ks, vd := pop.CalcKs(sampleSize, p1)
+		ks, vd := pop.CalcKs(sampleSize, src, p1)
 		res := CalcRes{
 			Index:  []int{i},
 			Ks:     ks,
 			Vd:     vd,
 			NumGen: numGen,
 		}
-		res.Cm, res.Ct, res.Cr, res.Cs = pop.CalcCov(sampleSize, maxL, p1)
+		res.Cm, res.Ct, res.Cr, res.Cs = pop.CalcCov(sampleSize, maxL, src, p1)
 		calcResults = append(calcResults, res)
 
 		for j := i + 1; j < len(pops); j++ {
 			p2 := pops[j]
-			ks, vd := pop.CrossKs(sampleSize, p1, p2)
+			ks, vd := pop.CrossKs(sampleSize, src, p1, p2)
 			res := CalcRes{
 				Index:  []int{i, j},
 				Ks:     ks,
 				Vd:     vd,
 				NumGen: numGen,
 			}
-			res.Cm, res.Ct, res.Cr, res.Cs = pop.CrossCov(sampleSize, maxL, p1, p2)
+			res.Cm, res.Ct, res.Cr, res.Cs = pop.CrossCov(sampleSize, maxL, src, p1, p2)
 			calcResults = append(calcResults, res)
 		}
 	}
@@ -210,14 +211,14 @@ func calculateResults(pops []*pop.Pop, numGen int) []CalcRes {
 
 		p1 := pops[0]
 		others := pops[1:]
-		ks, vd := pop.CalcKs(sampleSize, p1, others...)
+		ks, vd := pop.CalcKs(sampleSize, src, p1, others...)
 		res := CalcRes{
 			Index:  indices,
 			Ks:     ks,
 			Vd:     vd,
 			NumGen: numGen,
 		}
-		res.Cm, res.Ct, res.Cr, res.Cs = pop.CalcCov(sampleSize, maxL, p1, others...)
+		res.Cm, res.Ct, res.Cr, res.Cs = pop.CalcCov(sampleSize, maxL, src, p1, others...)
 		calcResults = append(calcResults, res)
 	}
 
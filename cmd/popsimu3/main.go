@@ -64,13 +64,18 @@ func main() {
 	write(w, resChan, *maxl, "-1")
 
 	var communities []Community
-	dilution := pop.Dilution{Factor: 0.1}
 	for _, p := range pp {
-		p1 := dilution.Reduce(p)
-		pop.Recover(p1, p.Size())
-		p2 := dilution.Reduce(p)
-		pop.Recover(p2, p.Size())
-		communities = append(communities, Community{p1, p2})
+		// split the ancestor into two founder populations of 10% of its size.
+		founderSize := p.Size() / 10
+		src := rand.NewSource(time.Now().UnixNano())
+		daughters, err := pop.Split(p, []int{founderSize, founderSize}, src)
+		if err != nil {
+			panic(err)
+		}
+		for _, d := range daughters {
+			pop.Recover(d, p.Size())
+		}
+		communities = append(communities, Community(daughters))
 	}
 
 	for i := 0; i <= *sampleTime; i++ {
@@ -110,6 +115,11 @@ func generatePopulations(pc pop.Config, num int) []*pop.Pop {
 		r := rand.New(src)
 		p := pop.New()
 		g := pop.NewRandomPopGenerator(r, pc.Size, pc.Length, []byte(pc.Alphabet))
+		landscape, err := pop.NewLandscape([]byte(pc.Alphabet), pc.Length, pc.Landscape, src)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		g.Landscape = landscape
 		g.Operate(p)
 		pp = append(pp, p)
 	}
@@ -129,7 +139,9 @@ func evolute(cc []Community, pcList []pop.Config, numGen, ncpu int) {
 	for i := 0; i < ncpu; i++ {
 		go func() {
 			for c := range cChan {
-				simu.Moran(c, pcList, numGen)
+				if err := simu.Moran(c, pcList, numGen); err != nil {
+					log.Fatalln(err)
+				}
 				done <- true
 			}
 
@@ -102,6 +102,7 @@ type calculators struct {
 	ks         *calculator.Ks
 	ct         *calculator.AutoCovFFTW
 	t2, t3, t4 []float64
+	neutrality []pop.Neutrality
 }
 
 func (c *calculators) Increment(xs []float64) {
@@ -117,6 +118,7 @@ func (c *calculators) Append(c2 *calculators) {
 	c.t2 = append(c.t2, c2.t2...)
 	c.t3 = append(c.t3, c2.t3...)
 	c.t4 = append(c.t4, c2.t4...)
+	c.neutrality = append(c.neutrality, c2.neutrality...)
 }
 
 type calcConfig struct {
@@ -146,6 +148,8 @@ func calc(simResChan chan popConfig, seqLen int) chan calcConfig {
 			t2 := pop.CalcT2(res.p, sampleSize)
 			t3 := pop.CalcT3(res.p, sampleSize)
 			t4 := pop.CalcT4(res.p, sampleSize)
+			src := rand.NewSource(time.Now().UnixNano())
+			nt := pop.CalcNeutrality(sampleSize, src, res.p)
 
 			cc.c = &calculators{}
 			cc.c.ks = ks
@@ -153,6 +157,7 @@ func calc(simResChan chan popConfig, seqLen int) chan calcConfig {
 			cc.c.t2 = t2
 			cc.c.t3 = t3
 			cc.c.t4 = t4
+			cc.c.neutrality = []pop.Neutrality{nt}
 
 			calcChan <- cc
 		}
@@ -199,6 +204,7 @@ func collect(calcChan chan calcConfig) []Result {
 		res.T2 = c.t2
 		res.T3 = c.t3
 		res.T4 = c.t4
+		res.Neutrality = c.neutrality
 		results = append(results, res)
 	}
 
@@ -209,6 +215,11 @@ func newPop(c pop.Config, src rand.Source) *pop.Pop {
 	p := pop.New()
 	r := rand.New(src)
 	g := pop.NewRandomPopGenerator(r, c.Size, c.Length, []byte(c.Alphabet))
+	landscape, err := pop.NewLandscape([]byte(c.Alphabet), c.Length, c.Landscape, src)
+	if err != nil {
+		panic(err)
+	}
+	g.Landscape = landscape
 	g.Operate(p)
 	return p
 }
@@ -256,14 +267,25 @@ func simu(c pop.Config) *pop.Pop {
 	defer rng.Free()
 	rng.Seed(time.Now().UnixNano())
 
+	fitness, err := pop.NewFitnessMap(c.FitnessMap, c.TruncationFraction)
+	if err != nil {
+		panic(err)
+	}
+
 	var sampler pop.Sampler
 	switch c.SampleMethod {
 	case "WrightFisher":
-		sampler = pop.NewWrightFisherSampler(rng)
+		s := pop.NewWrightFisherSampler(rng)
+		s.Fitness = fitness
+		sampler = s
 	case "LinearSelection":
-		sampler = pop.NewLinearSelectionSampler(rng)
+		s := pop.NewLinearSelectionSampler(rng)
+		s.Fitness = fitness
+		sampler = s
 	default:
-		sampler = pop.NewMoranSampler(rng)
+		s := pop.NewMoranSampler(rng)
+		s.Fitness = fitness
+		sampler = s
 	}
 
 	mutationEvent := &pop.Event{
@@ -8,6 +8,7 @@ type Result struct {
 	Config     pop.Config
 	C          CovResult
 	T2, T3, T4 []float64
+	Neutrality []pop.Neutrality
 }
 
 type CovResult struct {
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/mingzhi/popsimu/pop"
+	"github.com/mingzhi/popsimu/simu"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func main() {
+	app := kingpin.New("scenario-simu", "Moran simulation of populations with splits and merges")
+	app.Version("0.1")
+
+	scenarioFile := app.Arg("scenario-file", "scenario file of population configs and demographic events").Required().String()
+	outFile := app.Arg("output-file", "output file").Required().String()
+
+	kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	s := parseScenario(*scenarioFile)
+	for _, pc := range s.Populations {
+		fmt.Println(&pc)
+	}
+	// the seed is printed, so that runs without one can be replayed.
+	if s.Seed == 0 {
+		s.Seed = time.Now().UnixNano()
+	}
+	fmt.Printf("Seed: %d\n", s.Seed)
+	src := rand.NewSource(s.Seed)
+
+	pops := []*pop.Pop{}
+	for _, pc := range s.Populations {
+		pops = append(pops, generatePopulation(pc, src))
+	}
+
+	pops, _, records, err := simu.RunScenario(pops, s.Populations, s.Events, s.Generations, src)
+	for _, r := range records {
+		fmt.Println(r)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	w, err := os.Create(*outFile)
+	if err != nil {
+		panic(err)
+	}
+	defer w.Close()
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(pops); err != nil {
+		panic(err)
+	}
+}
+
+// parseScenario parse a JSON Scenario
+func parseScenario(file string) (s pop.Scenario) {
+	f, err := os.Open(file)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	if err := decoder.Decode(&s); err != nil {
+		log.Fatalln(err)
+	}
+	return
+}
+
+func generatePopulation(pc pop.Config, src rand.Source) *pop.Pop {
+	r := rand.New(src)
+	p := pop.New()
+	g := pop.NewRandomPopGenerator(r, pc.Size, pc.Length, []byte(pc.Alphabet))
+	landscape, err := pop.NewLandscape([]byte(pc.Alphabet), pc.Length, pc.Landscape, src)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	g.Landscape = landscape
+	g.Operate(p)
+	return p
+}
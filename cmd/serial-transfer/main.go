@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/mingzhi/popsimu/pop"
+	"github.com/mingzhi/popsimu/simu"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func main() {
+	app := kingpin.New("serial-transfer", "Serial-transfer simulation of a population")
+	app.Version("0.1")
+
+	configFile := app.Arg("config-file", "population config file").Required().String()
+	outFile := app.Arg("output-file", "output file").Required().String()
+
+	kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	pc := parsePopConfig(*configFile)
+	fmt.Println(&pc)
+	src := rand.NewSource(time.Now().UnixNano())
+	pp := generatePopulation(pc, src)
+
+	records, err := simu.SerialTransfer(pp, pc, src)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, r := range records {
+		fmt.Printf("Transfer %d: %f generations, %d -> %d genomes, Ks %f, mean fitness %f\n",
+			r.Transfer, r.Generations, r.Bottleneck, r.Size, r.Ks, r.MeanFit)
+	}
+
+	w, err := os.Create(*outFile)
+	if err != nil {
+		panic(err)
+	}
+	defer w.Close()
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(records); err != nil {
+		panic(err)
+	}
+}
+
+// parsePopConfig parse a JSON PopConfig
+func parsePopConfig(file string) (pc pop.Config) {
+	f, err := os.Open(file)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	if err := decoder.Decode(&pc); err != nil {
+		log.Fatalln(err)
+	}
+	return
+}
+
+func generatePopulation(pc pop.Config, src rand.Source) *pop.Pop {
+	r := rand.New(src)
+	p := pop.New()
+	g := pop.NewRandomPopGenerator(r, pc.Size, pc.Length, []byte(pc.Alphabet))
+	landscape, err := pop.NewLandscape([]byte(pc.Alphabet), pc.Length, pc.Landscape, src)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	g.Landscape = landscape
+	g.Operate(p)
+	return p
+}
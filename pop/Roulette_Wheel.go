@@ -2,6 +2,7 @@ package pop
 
 import (
 	"math/rand"
+	"sort"
 )
 
 // RouletteWheel is a random generator.
@@ -14,12 +15,16 @@ func NewRouletteWheel(src rand.Source) *RouletteWheel {
 	return &RouletteWheel{r: rand.New(src)}
 }
 
-// Select return a select index.
+// Select return a select index,
+// which is uniformly random if no weight is positive.
 func (r *RouletteWheel) Select(weights []float64) (index int) {
 	totalWeight := 0.0
 	for i := 0; i < len(weights); i++ {
 		totalWeight += weights[i]
 	}
+	if !(totalWeight > 0) {
+		return r.r.Intn(len(weights))
+	}
 
 	v := r.r.Float64()
 
@@ -34,3 +39,30 @@ func (r *RouletteWheel) Select(weights []float64) (index int) {
 
 	return
 }
+
+// cumulate returns the cumulative sums of the weights.
+func cumulate(weights []float64) []float64 {
+	cum := make([]float64, len(weights))
+	total := 0.0
+	for i, w := range weights {
+		total += w
+		cum[i] = total
+	}
+	return cum
+}
+
+// selectCumulative returns an index selected by the cumulative weights,
+// which is uniformly random if no weight is positive.
+func (r *RouletteWheel) selectCumulative(cum []float64) int {
+	n := len(cum)
+	total := cum[n-1]
+	if !(total > 0) {
+		return r.r.Intn(n)
+	}
+	v := r.r.Float64() * total
+	index := sort.Search(n, func(i int) bool { return cum[i] > v })
+	if index == n {
+		index = n - 1
+	}
+	return index
+}
@@ -0,0 +1,229 @@
+package pop
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Segment is a stretch [Start, End) of a genome descending from a lineage.
+type Segment struct {
+	Start, End int
+	Lineage    *Lineage
+}
+
+// Ancestry is a list of segments covering a genome, in the order of positions.
+//
+// In the ARG mode, each genome carries its own ancestry,
+// so that transfers are recorded in the genealogies of the segments.
+type Ancestry []Segment
+
+// NewAncestry returns an ancestry of a single segment.
+func NewAncestry(length int, l *Lineage) Ancestry {
+	return Ancestry{Segment{Start: 0, End: length, Lineage: l}}
+}
+
+// At returns the lineage of the position.
+func (a Ancestry) At(pos int) *Lineage {
+	i := sort.Search(len(a), func(i int) bool { return a[i].End > pos })
+	if i == len(a) || a[i].Start > pos {
+		return nil
+	}
+	return a[i].Lineage
+}
+
+// inherit returns the ancestry of a daughter born at time t,
+// in which each segment descends from a new lineage.
+// Adjacent segments of the same lineage are merged.
+func (a Ancestry) inherit(t int) Ancestry {
+	daughter := Ancestry{}
+	for i, s := range a {
+		if i > 0 && a[i-1].Lineage == s.Lineage && a[i-1].End == s.Start {
+			daughter[len(daughter)-1].End = s.End
+			continue
+		}
+		l := &Lineage{BirthTime: t, Parent: s.Lineage}
+		daughter = append(daughter, Segment{Start: s.Start, End: s.End, Lineage: l})
+	}
+	return daughter
+}
+
+// replace replaces the stretch [start, end) by the pieces.
+func (a Ancestry) replace(start, end int, pieces []Segment) Ancestry {
+	res := Ancestry{}
+	for _, s := range a {
+		if s.Start < start {
+			res = append(res, Segment{Start: s.Start, End: minInt(s.End, start), Lineage: s.Lineage})
+		}
+	}
+	res = append(res, pieces...)
+	for _, s := range a {
+		if s.End > end {
+			res = append(res, Segment{Start: maxInt(s.Start, end), End: s.End, Lineage: s.Lineage})
+		}
+	}
+	return res
+}
+
+// transferAncestry records a transfer of the stretch [start, end)
+// from the donor to the recipient at time t.
+// The donor lineages of the stretch split into two daughters:
+// one stays in the donor, and the other goes to the recipient.
+func transferAncestry(recipient, donor Ancestry, start, end, t int) (Ancestry, Ancestry) {
+	donorPieces := []Segment{}
+	recipientPieces := []Segment{}
+	for _, s := range donor {
+		if s.End <= start || s.Start >= end {
+			continue
+		}
+		a, b := createNewLineages(s.Lineage, t)
+		lo, hi := maxInt(s.Start, start), minInt(s.End, end)
+		donorPieces = append(donorPieces, Segment{Start: lo, End: hi, Lineage: a})
+		recipientPieces = append(recipientPieces, Segment{Start: lo, End: hi, Lineage: b})
+	}
+	return recipient.replace(start, end, recipientPieces), donor.replace(start, end, donorPieces)
+}
+
+// recordTransfer records a transfer of the stretch [start, end)
+// from the genome d of the donor population to the genome r of the recipient one,
+// if the recipient population is in the ARG mode.
+// The stretch wraps around the end of a circled genome.
+func recordTransfer(recipient *Pop, r int, donor *Pop, d int, start, end, length int) {
+	if recipient.Ancestries == nil {
+		return
+	}
+
+	var donorAncestry Ancestry
+	if donor.Ancestries != nil {
+		donorAncestry = donor.Ancestries[d]
+	} else if d < len(donor.Lineages) {
+		donorAncestry = NewAncestry(length, donor.Lineages[d])
+	} else {
+		donorAncestry = NewAncestry(length, nil)
+	}
+
+	t := recipient.NumGeneration
+	recipientAncestry := recipient.Ancestries[r]
+	if end <= length {
+		recipientAncestry, donorAncestry = transferAncestry(recipientAncestry, donorAncestry, start, end, t)
+	} else {
+		recipientAncestry, donorAncestry = transferAncestry(recipientAncestry, donorAncestry, start, length, t)
+		if recipient.Circled {
+			recipientAncestry, donorAncestry = transferAncestry(recipientAncestry, donorAncestry, 0, minInt(end-length, start), t)
+		}
+	}
+
+	recipient.Ancestries[r] = recipientAncestry
+	if donor.Ancestries != nil {
+		donor.Ancestries[d] = donorAncestry
+	}
+}
+
+// EnableARG switches on the ARG mode of the population,
+// in which each genome tracks the ancestry of its segments.
+func (p *Pop) EnableARG() {
+	if len(p.Lineages) < p.Size() {
+		p.NewLineages()
+	}
+	p.Ancestries = make([]Ancestry, p.Size())
+	for i := 0; i < p.Size(); i++ {
+		p.Ancestries[i] = NewAncestry(p.RefLength(), p.Lineages[i])
+	}
+}
+
+// LocalLineages returns the lineages of the genomes at the position.
+// Without the ARG mode, they are the clonal lineages.
+func LocalLineages(p *Pop, pos int) Lineages {
+	if p.Ancestries == nil {
+		return p.Lineages
+	}
+	lineages := Lineages{}
+	for _, a := range p.Ancestries {
+		lineages = append(lineages, a.At(pos))
+	}
+	return lineages
+}
+
+// LocalNewick returns the genealogy at the position in Newick format,
+// of the genomes at the given indices, or of the whole population.
+func LocalNewick(p *Pop, pos int, indices ...int) string {
+	return newick(LocalLineages(p, pos), p.NumGeneration+1, indices)
+}
+
+// CalcLocalT2 returns the coalescent times of randomly sampled pairs of genomes
+// at the position.
+func CalcLocalT2(p *Pop, pos, sampleSize int, src rand.Source) []float64 {
+	lineages := LocalLineages(p, pos)
+	tree := NewLineageTree(lineages)
+	r := rand.New(src)
+	coalTimes := []float64{}
+	if len(lineages) < 2 {
+		return coalTimes
+	}
+	for i := 0; i < sampleSize; i++ {
+		a := r.Intn(len(lineages))
+		b := r.Intn(len(lineages) - 1)
+		if b >= a {
+			b++
+		}
+		t := tree.CoalTime(lineages[a], lineages[b])
+		coalTimes = append(coalTimes, float64(p.NumGeneration-t+1))
+	}
+	return coalTimes
+}
+
+// CalcSiteTMRCA returns the time to the most recent common ancestor
+// of the whole population at each site.
+func CalcSiteTMRCA(p *Pop) []float64 {
+	length := p.RefLength()
+	tmrca := make([]float64, length)
+	if p.Ancestries == nil {
+		v := CalcTMRCA(p)
+		for i := range tmrca {
+			tmrca[i] = v
+		}
+		return tmrca
+	}
+
+	// the local genealogy changes only at the breakpoints.
+	breakpoints := []int{}
+	all := Lineages{}
+	for _, a := range p.Ancestries {
+		for _, s := range a {
+			breakpoints = append(breakpoints, s.Start)
+			all = append(all, s.Lineage)
+		}
+	}
+	sort.Ints(breakpoints)
+	tree := NewLineageTree(all)
+	for i, start := range breakpoints {
+		if i > 0 && breakpoints[i-1] == start {
+			continue
+		}
+		end := length
+		for j := i + 1; j < len(breakpoints); j++ {
+			if breakpoints[j] > start {
+				end = breakpoints[j]
+				break
+			}
+		}
+		t := tree.MRCATime(LocalLineages(p, start)...)
+		for pos := start; pos < end; pos++ {
+			tmrca[pos] = float64(p.NumGeneration - t + 1)
+		}
+	}
+	return tmrca
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,89 @@
+package pop
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func runMoranARG(size, length, numGen int, traRate float64, seed int64) *Pop {
+	src := rand.NewSource(seed)
+	r := rand.New(src)
+	p := New()
+	NewRandomPopGenerator(r, size, length, []byte{1, 2, 3, 4}).Operate(p)
+	p.EnableARG()
+	moran := NewMoranSampler(src)
+	transfer := NewSimpleTransfer(NewConstantFrag(length/5), src)
+	for i := 0; i < numGen; i++ {
+		moran.Operate(p)
+		if r.Float64() < traRate {
+			transfer.Operate(p)
+		}
+	}
+	return p
+}
+
+func TestAncestryTransfer(t *testing.T) {
+	p := New()
+	for i := 0; i < 3; i++ {
+		p.Genomes = append(p.Genomes, &NeutralGenome{Sequence: make(ByteSequence, 10)})
+	}
+	p.EnableARG()
+	p.NumGeneration = 5
+	recordTransfer(p, 0, p, 1, 2, 5, 10)
+
+	if n := len(p.Ancestries[0]); n != 3 {
+		t.Errorf("Expect %d segments, but got %d\n", 3, n)
+	}
+	if n := len(p.Ancestries[1]); n != 3 {
+		t.Errorf("Expect %d segments, but got %d\n", 3, n)
+	}
+	for pos, expected := range map[int]float64{0: 6, 2: 1, 4: 1, 5: 6, 9: 6} {
+		tree := NewLineageTree(LocalLineages(p, pos))
+		ls := LocalLineages(p, pos)
+		res := float64(p.NumGeneration - tree.CoalTime(ls[0], ls[1]) + 1)
+		if res != expected {
+			t.Errorf("Expect T2 %f at %d, but got %f\n", expected, pos, res)
+		}
+	}
+
+	// a transfer wrapping around a circled genome.
+	p.Circled = true
+	p.NumGeneration = 7
+	recordTransfer(p, 2, p, 0, 8, 12, 10)
+	for pos, expected := range map[int]float64{0: 1, 1: 1, 2: 8, 8: 1, 9: 1} {
+		ls := LocalLineages(p, pos)
+		tree := NewLineageTree(ls)
+		res := float64(p.NumGeneration - tree.CoalTime(ls[0], ls[2]) + 1)
+		if res != expected {
+			t.Errorf("Expect T2 %f at %d, but got %f\n", expected, pos, res)
+		}
+	}
+}
+
+func TestAncestryClonal(t *testing.T) {
+	// without transfers, local genealogies are the clonal one.
+	p := runMoranARG(10, 20, 1000, 0, 1)
+	for _, pos := range []int{0, 10, 19} {
+		if expected, res := Newick(p), LocalNewick(p, pos); res != expected {
+			t.Errorf("Expect %s, but got %s at %d\n", expected, res, pos)
+		}
+	}
+}
+
+func TestAncestryPrune(t *testing.T) {
+	p := runMoranARG(10, 50, 2000, 0.5, 1)
+	tmrca := CalcSiteTMRCA(p)
+	t2 := CalcLocalT2(p, 25, 100, rand.NewSource(1))
+
+	PruneLineages(p)
+	for pos, v := range CalcSiteTMRCA(p) {
+		if v != tmrca[pos] {
+			t.Errorf("Expect TMRCA %f at %d, but got %f\n", tmrca[pos], pos, v)
+		}
+	}
+	for i, v := range CalcLocalT2(p, 25, 100, rand.NewSource(1)) {
+		if v != t2[i] {
+			t.Errorf("Expect T2 %f, but got %f\n", t2[i], v)
+		}
+	}
+}
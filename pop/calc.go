@@ -0,0 +1,260 @@
+package pop
+
+import (
+	"math/rand"
+
+	"github.com/mingzhi/popsimu/corr"
+)
+
+// CalcKs calculates the mean pairwise divergence (Ks)
+// and its variance (Vd) over pairs of genomes,
+// which are randomly sampled from the populations pooled together.
+func CalcKs(sampleSize int, src rand.Source, p1 *Pop, others ...*Pop) (ks, vd float64) {
+	matrix := sampleDiffMatrix(sampleSize, src, p1, others...)
+	ks, vd = calcKs(matrix)
+	return
+}
+
+// CrossKs calculates the mean pairwise divergence (Ks)
+// and its variance (Vd) between two populations,
+// by sampling one genome from each population.
+func CrossKs(sampleSize int, src rand.Source, p1, p2 *Pop) (ks, vd float64) {
+	matrix := crossDiffMatrix(sampleSize, src, p1, p2)
+	ks, vd = calcKs(matrix)
+	return
+}
+
+// CalcCov calculates the correlation profiles up to maxl
+// over pairs of genomes sampled from the populations pooled together.
+//
+// It returns the mutational correlation (cm), the total correlation (ct),
+// the correlation of the mean profile (cr), and the structure correlation (cs),
+// which satisfy vd = ct - cm and cs = ct - cr at every lag.
+func CalcCov(sampleSize, maxl int, src rand.Source, p1 *Pop, others ...*Pop) (cm, ct, cr, cs []float64) {
+	matrix := sampleDiffMatrix(sampleSize, src, p1, others...)
+	cm, ct, cr, cs = calcCov(matrix, maxl, p1.Circled)
+	return
+}
+
+// CrossCov calculates the correlation profiles up to maxl
+// between two populations, by sampling one genome from each population.
+func CrossCov(sampleSize, maxl int, src rand.Source, p1, p2 *Pop) (cm, ct, cr, cs []float64) {
+	matrix := crossDiffMatrix(sampleSize, src, p1, p2)
+	cm, ct, cr, cs = calcCov(matrix, maxl, p1.Circled)
+	return
+}
+
+func calcCov(matrix [][]float64, maxl int, circular bool) (cm, ct, cr, cs []float64) {
+	if len(matrix) == 0 {
+		return
+	}
+	cm, ct = calcCmFFT(matrix, maxl, circular)
+	cr, _ = calcCmFFT([][]float64{average(matrix)}, maxl, circular)
+	cs = calcCs(matrix, maxl, circular)
+	return
+}
+
+// sampleDiffMatrix randomly samples pairs of distinct genomes
+// from the pooled populations, and returns their difference vectors.
+// Genomes are aligned in the ancestral coordinates.
+func sampleDiffMatrix(sampleSize int, src rand.Source, p1 *Pop, others ...*Pop) [][]float64 {
+	genomes := []Genome{}
+	for _, p := range append([]*Pop{p1}, others...) {
+		genomes = append(genomes, p.Genomes...)
+	}
+
+	r := rand.New(src)
+	matrix := [][]float64{}
+	if len(genomes) < 2 {
+		return matrix
+	}
+	length := p1.RefLength()
+	for i := 0; i < sampleSize; i++ {
+		a := r.Intn(len(genomes))
+		b := r.Intn(len(genomes) - 1)
+		if b >= a {
+			b++
+		}
+		matrix = append(matrix, diff(AlignedSeq(genomes[a], length), AlignedSeq(genomes[b], length)))
+	}
+	return matrix
+}
+
+// crossDiffMatrix randomly samples one genome from each population,
+// and returns the difference vectors of the pairs.
+func crossDiffMatrix(sampleSize int, src rand.Source, p1, p2 *Pop) [][]float64 {
+	r := rand.New(src)
+	matrix := [][]float64{}
+	if p1.Size() == 0 || p2.Size() == 0 {
+		return matrix
+	}
+	length := p1.RefLength()
+	for i := 0; i < sampleSize; i++ {
+		a := p1.Genomes[r.Intn(p1.Size())]
+		b := p2.Genomes[r.Intn(p2.Size())]
+		matrix = append(matrix, diff(AlignedSeq(a, length), AlignedSeq(b, length)))
+	}
+	return matrix
+}
+
+// diff returns a vector, in which 1 indicates a difference
+// between the two sequences at the position, and 0 otherwise.
+// A gap is different from any base, as in an alignment.
+func diff(a, b []byte) []float64 {
+	d := make([]float64, len(a))
+	for i := 0; i < len(a); i++ {
+		if a[i] != b[i] {
+			d[i] = 1
+		}
+	}
+	return d
+}
+
+// calcKs returns the mean (ks) and the variance (vd)
+// of the divergences of the rows in the matrix.
+func calcKs(matrix [][]float64) (ks, vd float64) {
+	if len(matrix) == 0 {
+		return
+	}
+
+	var m1, m2 float64
+	for _, row := range matrix {
+		d := mean(row)
+		m1 += d
+		m2 += d * d
+	}
+	n := float64(len(matrix))
+	ks = m1 / n
+	vd = m2/n - ks*ks
+	return
+}
+
+// calcCm calculates the mutational correlation (cm)
+// and the total correlation (ct) by brute force.
+func calcCm(matrix [][]float64, maxl int, circular bool) (cm, ct []float64) {
+	length := len(matrix[0])
+	maxl = maxLag(maxl, length)
+	cm = make([]float64, maxl)
+	ct = make([]float64, maxl)
+	ks, _ := calcKs(matrix)
+	for _, row := range matrix {
+		d := mean(row)
+		for l := 0; l < maxl; l++ {
+			var p2 float64
+			for s := 0; s < length; s++ {
+				if s+l < length {
+					p2 += row[s] * row[s+l]
+				} else if circular {
+					p2 += row[s] * row[s+l-length]
+				}
+			}
+			p2 /= float64(numPairs(length, l, circular))
+			cm[l] += p2 - d*d
+			ct[l] += p2
+		}
+	}
+
+	n := float64(len(matrix))
+	for l := 0; l < maxl; l++ {
+		cm[l] /= n
+		ct[l] = ct[l]/n - ks*ks
+	}
+	return
+}
+
+// calcCmFFT calculates the mutational correlation (cm)
+// and the total correlation (ct) using FFT.
+func calcCmFFT(matrix [][]float64, maxl int, circular bool) (cm, ct []float64) {
+	length := len(matrix[0])
+	maxl = maxLag(maxl, length)
+	cm = make([]float64, maxl)
+	ct = make([]float64, maxl)
+	ks, _ := calcKs(matrix)
+	for _, row := range matrix {
+		d := mean(row)
+		auto := corr.AutoCorrFFT(row, circular)
+		for l := 0; l < maxl; l++ {
+			p2 := auto[l] / float64(numPairs(length, l, circular))
+			cm[l] += p2 - d*d
+			ct[l] += p2
+		}
+	}
+
+	n := float64(len(matrix))
+	for l := 0; l < maxl; l++ {
+		cm[l] /= n
+		ct[l] = ct[l]/n - ks*ks
+	}
+	return
+}
+
+// calcCs calculates the structure correlation (cs),
+// which is the covariance between two sites across the rows,
+// averaged over all pairs of sites at the same distance.
+func calcCs(matrix [][]float64, maxl int, circular bool) (cs []float64) {
+	length := len(matrix[0])
+	maxl = maxLag(maxl, length)
+	cs = make([]float64, maxl)
+	m := average(matrix)
+	centered := make([]float64, length)
+	for _, row := range matrix {
+		for s := 0; s < length; s++ {
+			centered[s] = row[s] - m[s]
+		}
+		auto := corr.AutoCorrFFT(centered, circular)
+		for l := 0; l < maxl; l++ {
+			cs[l] += auto[l] / float64(numPairs(length, l, circular))
+		}
+	}
+
+	n := float64(len(matrix))
+	for l := 0; l < maxl; l++ {
+		cs[l] /= n
+	}
+	return
+}
+
+// average returns the column means of the matrix.
+func average(matrix [][]float64) []float64 {
+	if len(matrix) == 0 {
+		return nil
+	}
+	m := make([]float64, len(matrix[0]))
+	for _, row := range matrix {
+		for i, v := range row {
+			m[i] += v
+		}
+	}
+	for i := range m {
+		m[i] /= float64(len(matrix))
+	}
+	return m
+}
+
+// maxLag limits the max lag by the length of the sequences.
+func maxLag(maxl, length int) int {
+	if maxl > length || maxl <= 0 {
+		return length
+	}
+	return maxl
+}
+
+// numPairs returns the number of site pairs at distance l.
+func numPairs(length, l int, circular bool) int {
+	if circular {
+		return length
+	}
+	return length - l
+}
+
+// mean returns the average of the values.
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var m float64
+	for _, v := range values {
+		m += v
+	}
+	return m / float64(len(values))
+}
@@ -7,6 +7,65 @@ import (
 	"testing"
 )
 
+func TestCalcKs(t *testing.T) {
+	tolerance := 1e-8
+	p := New()
+	p.Genomes = []Genome{
+		&NeutralGenome{Sequence: ByteSequence("AAAAAAAAAA")},
+		&NeutralGenome{Sequence: ByteSequence("AAAAAAAAAA")},
+		&NeutralGenome{Sequence: ByteSequence("TTAAAAAAAA")},
+	}
+	// pairwise divergences are 0, 0.2 and 0.2.
+	expKs := 0.4 / 3.0
+	expVd := 0.08/3.0 - expKs*expKs
+	ks, vd := CalcKs(100000, rand.NewSource(1), p)
+	if math.Abs(ks-expKs) > 1e-2 {
+		t.Errorf("Expect Ks %f, but got %f\n", expKs, ks)
+	}
+	if math.Abs(vd-expVd) > 1e-2 {
+		t.Errorf("Expect Vd %f, but got %f\n", expVd, vd)
+	}
+
+	matrix := [][]float64{
+		diff(p.Genomes[0].Seq(), p.Genomes[1].Seq()),
+		diff(p.Genomes[0].Seq(), p.Genomes[2].Seq()),
+		diff(p.Genomes[1].Seq(), p.Genomes[2].Seq()),
+	}
+	ks, vd = calcKs(matrix)
+	if math.Abs(ks-expKs) > tolerance {
+		t.Errorf("Expect Ks %f, but got %f\n", expKs, ks)
+	}
+	if math.Abs(vd-expVd) > tolerance {
+		t.Errorf("Expect Vd %f, but got %f\n", expVd, vd)
+	}
+}
+
+func TestCrossKs(t *testing.T) {
+	tolerance := 1e-8
+	p1 := New()
+	p1.Genomes = []Genome{
+		&NeutralGenome{Sequence: ByteSequence("AAAAAAAAAA")},
+		&NeutralGenome{Sequence: ByteSequence("AAAAAAAAAA")},
+	}
+	p2 := New()
+	p2.Genomes = []Genome{
+		&NeutralGenome{Sequence: ByteSequence("TTTTTAAAAA")},
+	}
+	ks, vd := CrossKs(100, rand.NewSource(1), p1, p2)
+	if math.Abs(ks-0.5) > tolerance {
+		t.Errorf("Expect Ks %f, but got %f\n", 0.5, ks)
+	}
+	if math.Abs(vd) > tolerance {
+		t.Errorf("Expect Vd %f, but got %f\n", 0.0, vd)
+	}
+
+	// pooling both populations.
+	ks, _ = CalcKs(100000, rand.NewSource(1), p1, p2)
+	if math.Abs(ks-1.0/3.0) > 1e-2 {
+		t.Errorf("Expect Ks %f, but got %f\n", 1.0/3.0, ks)
+	}
+}
+
 func TestCalcCm(t *testing.T) {
 	tolerance := 1e-8
 	size := 10
@@ -17,36 +76,37 @@ func TestCalcCm(t *testing.T) {
 			matrix[i] = append(matrix[i], rand.Float64())
 		}
 	}
-	circular := true
-	mutCov1, totCov1 := calcCm(matrix, len(matrix[0]), circular)
-	mutCov2, totCov2 := calcCmFFT(matrix, len(matrix[0]), circular)
-
-	if len(mutCov1) != len(mutCov2) {
-		t.Errorf("Different length of results (mutCov): %d vs %d\n", len(mutCov1), len(mutCov2))
-	}
-
-	if len(totCov1) != len(totCov2) {
-		t.Errorf("Different length of results (totCov): %d vs %d\n", len(totCov1), len(totCov2))
-	}
+	for _, circular := range []bool{true, false} {
+		mutCov1, totCov1 := calcCm(matrix, len(matrix[0]), circular)
+		mutCov2, totCov2 := calcCmFFT(matrix, len(matrix[0]), circular)
 
-	for i := 0; i < len(matrix[0]); i++ {
-		if math.IsNaN(mutCov1[i]) {
-			t.Errorf("NaN at %d\n", i)
+		if len(mutCov1) != len(mutCov2) {
+			t.Errorf("Different length of results (mutCov): %d vs %d\n", len(mutCov1), len(mutCov2))
 		}
-		if math.IsNaN(mutCov2[i]) {
-			t.Errorf("NaN at %d\n", i)
-		}
-		if math.IsNaN(totCov1[i]) {
-			t.Errorf("NaN at %d\n", i)
-		}
-		if math.IsNaN(totCov2[i]) {
-			t.Errorf("NaN at %d\n", i)
-		}
-		if math.Abs(mutCov1[i]-mutCov2[i]) > tolerance {
-			t.Errorf("Difference between result1 %f and result2 %f at %d\n", mutCov1[i], mutCov2[i], i)
+
+		if len(totCov1) != len(totCov2) {
+			t.Errorf("Different length of results (totCov): %d vs %d\n", len(totCov1), len(totCov2))
 		}
-		if math.Abs(totCov1[i]-totCov2[i]) > tolerance {
-			t.Errorf("Difference between result1 %f and result2 %f at %d\n", totCov1[i], totCov2[i], i)
+
+		for i := 0; i < len(matrix[0]); i++ {
+			if math.IsNaN(mutCov1[i]) {
+				t.Errorf("NaN at %d\n", i)
+			}
+			if math.IsNaN(mutCov2[i]) {
+				t.Errorf("NaN at %d\n", i)
+			}
+			if math.IsNaN(totCov1[i]) {
+				t.Errorf("NaN at %d\n", i)
+			}
+			if math.IsNaN(totCov2[i]) {
+				t.Errorf("NaN at %d\n", i)
+			}
+			if math.Abs(mutCov1[i]-mutCov2[i]) > tolerance {
+				t.Errorf("Difference between result1 %f and result2 %f at %d\n", mutCov1[i], mutCov2[i], i)
+			}
+			if math.Abs(totCov1[i]-totCov2[i]) > tolerance {
+				t.Errorf("Difference between result1 %f and result2 %f at %d\n", totCov1[i], totCov2[i], i)
+			}
 		}
 	}
 }
@@ -61,21 +121,22 @@ func TestDecomposition(t *testing.T) {
 			matrix[i] = append(matrix[i], rand.Float64())
 		}
 	}
-	circular := true
-	maxL := len(matrix[0])
-	cM, cT := calcCmFFT(matrix, maxL, circular)
-	cR, _ := calcCmFFT([][]float64{average(matrix)}, maxL, circular)
-	cS := calcCs(matrix, maxL, circular)
-	_, vd := calcKs(matrix)
-	for i := 0; i < len(cM); i++ {
-		vd1 := cT[i] - cM[i]
-		if math.Abs(vd1-vd) > tolerance {
-			t.Errorf("Difference between vd %f and vd1 %f at %d\n", vd, vd1, i)
-		}
-		cs1 := cS[i]
-		cs2 := cT[i] - cR[i]
-		if math.Abs(cs1-cs2) > tolerance {
-			t.Errorf("Difference between cS %f and (cT - cR) %f at %d\n", cs1, cs2, i)
+	for _, circular := range []bool{true, false} {
+		maxL := len(matrix[0])
+		cM, cT := calcCmFFT(matrix, maxL, circular)
+		cR, _ := calcCmFFT([][]float64{average(matrix)}, maxL, circular)
+		cS := calcCs(matrix, maxL, circular)
+		_, vd := calcKs(matrix)
+		for i := 0; i < len(cM); i++ {
+			vd1 := cT[i] - cM[i]
+			if math.Abs(vd1-vd) > tolerance {
+				t.Errorf("Difference between vd %f and vd1 %f at %d\n", vd, vd1, i)
+			}
+			cs1 := cS[i]
+			cs2 := cT[i] - cR[i]
+			if math.Abs(cs1-cs2) > tolerance {
+				t.Errorf("Difference between cS %f and (cT - cR) %f at %d\n", cs1, cs2, i)
+			}
 		}
 	}
 }
@@ -3,7 +3,6 @@ package pop
 import (
 	"math/rand"
 	"runtime"
-	"sort"
 )
 
 type sampleT func(p *Pop)
@@ -55,6 +54,7 @@ func sampleLineages(p *Pop, sampleSize, lineageSize int) chan Lineages {
 }
 
 func calcCoalTimes(c chan Lineages, p *Pop) []float64 {
+	tree := NewLineageTree(p.Lineages)
 	numWorker := runtime.GOMAXPROCS(0)
 	results := make(chan float64, numWorker)
 	done := make(chan bool)
@@ -62,7 +62,7 @@ func calcCoalTimes(c chan Lineages, p *Pop) []float64 {
 		go func() {
 			for lineages := range c {
 				var v float64
-				t := findMostRecentCoalescentTime(lineages)
+				t := tree.CoalTime(lineages...)
 				v = float64(p.NumGeneration - t + 1)
 				results <- v
 			}
@@ -85,67 +85,23 @@ func calcCoalTimes(c chan Lineages, p *Pop) []float64 {
 	return coalTimes
 }
 
-func findMostRecentCoalescentTime(lineages Lineages) int {
-	if len(lineages) == 0 {
-		return 0
-	} else if len(lineages) == 1 {
-		return lineages[0].BirthTime
-	} else {
-		birthTimes := []int{}
-		for i := 0; i < len(lineages); i++ {
-			a := lineages[i]
-			for j := i + 1; j < len(lineages); j++ {
-				b := lineages[j]
-				if a.BirthTime == b.BirthTime && a.Parent == b.Parent {
-					birthTimes = append(birthTimes, a.BirthTime)
-				}
-			}
-		}
-
-		if len(birthTimes) > 0 {
-			sort.Ints(birthTimes)
-			return birthTimes[0]
-		} else {
-			sort.Sort(ByBirthTimeReverse{lineages})
-			currentTime := lineages[0].BirthTime
-			for i := 0; i < len(lineages); i++ {
-				if lineages[i].BirthTime == currentTime {
-					lineages[i] = lineages[i].Parent
-				}
-			}
-			return findMostRecentCoalescentTime(lineages)
+// CalcAllT2 returns the coalescent times of all pairs of genomes.
+func CalcAllT2(p *Pop) []float64 {
+	tree := NewLineageTree(p.Lineages)
+	coalTimes := []float64{}
+	for i := 0; i < len(p.Lineages); i++ {
+		for j := i + 1; j < len(p.Lineages); j++ {
+			t := tree.CoalTime(p.Lineages[i], p.Lineages[j])
+			coalTimes = append(coalTimes, float64(p.NumGeneration-t+1))
 		}
 	}
+	return coalTimes
 }
 
-func findAllMostRecentCoalescentTime(lineages Lineages) int {
-	if len(lineages) == 0 {
-		return 0
-	} else if len(lineages) == 1 {
-		return lineages[0].BirthTime
-	} else {
-		birthTime := lineages[0].BirthTime
-		parent := lineages[0].Parent
-		found := true
-		for i := 0; i < len(lineages); i++ {
-			a := lineages[i]
-			if !(a.BirthTime == birthTime && a.Parent == parent) {
-				found = false
-				break
-			}
-		}
-
-		if !found {
-			sort.Sort(ByBirthTimeReverse{lineages})
-			currentTime := lineages[0].BirthTime
-			for i := 0; i < len(lineages); i++ {
-				if lineages[i].BirthTime == currentTime {
-					lineages[i] = lineages[i].Parent
-				}
-			}
-			return findMostRecentCoalescentTime(lineages)
-		} else {
-			return birthTime
-		}
-	}
+// CalcTMRCA returns the time to the most recent common ancestor
+// of the whole population.
+func CalcTMRCA(p *Pop) float64 {
+	tree := NewLineageTree(p.Lineages)
+	t := tree.MRCATime(p.Lineages...)
+	return float64(p.NumGeneration - t + 1)
 }
@@ -0,0 +1,96 @@
+package pop
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// findMostRecentCoalescentTime is the recursive reference implementation,
+// which walks up the lineages in the order of birth time.
+func findMostRecentCoalescentTime(lineages Lineages) int {
+	if len(lineages) == 0 {
+		return 0
+	} else if len(lineages) == 1 {
+		return lineages[0].BirthTime
+	}
+
+	birthTimes := []int{}
+	for i := 0; i < len(lineages); i++ {
+		a := lineages[i]
+		for j := i + 1; j < len(lineages); j++ {
+			b := lineages[j]
+			if a.BirthTime == b.BirthTime && a.Parent == b.Parent {
+				birthTimes = append(birthTimes, a.BirthTime)
+			}
+		}
+	}
+
+	if len(birthTimes) > 0 {
+		sort.Ints(birthTimes)
+		return birthTimes[0]
+	}
+
+	sort.Sort(ByBirthTimeReverse{lineages})
+	currentTime := lineages[0].BirthTime
+	for i := 0; i < len(lineages); i++ {
+		if lineages[i].BirthTime == currentTime {
+			lineages[i] = lineages[i].Parent
+		}
+	}
+	return findMostRecentCoalescentTime(lineages)
+}
+
+func runMoran(size, numGen int, seed int64) *Pop {
+	src := rand.NewSource(seed)
+	p := New()
+	NewRandomPopGenerator(rand.New(src), size, 10, []byte{1, 2, 3, 4}).Operate(p)
+	moran := NewMoranSampler(src)
+	for i := 0; i < numGen; i++ {
+		moran.Operate(p)
+	}
+	return p
+}
+
+func TestLineageTree(t *testing.T) {
+	for _, numGen := range []int{0, 10, 100, 1000} {
+		p := runMoran(8, numGen, int64(numGen))
+		tree := NewLineageTree(p.Lineages)
+		ls := p.Lineages
+		for i := 0; i < len(ls); i++ {
+			for j := i + 1; j < len(ls); j++ {
+				expected := findMostRecentCoalescentTime(Lineages{ls[i], ls[j]})
+				if res := tree.CoalTime(ls[i], ls[j]); res != expected {
+					t.Errorf("Expect T2 %d, but got %d, after %d steps\n", expected, res, numGen)
+				}
+				if res := tree.MRCATime(ls[i], ls[j]); res != expected {
+					t.Errorf("Expect MRCA %d, but got %d, after %d steps\n", expected, res, numGen)
+				}
+				for k := j + 1; k < len(ls); k++ {
+					expected := findMostRecentCoalescentTime(Lineages{ls[i], ls[j], ls[k]})
+					if res := tree.CoalTime(ls[i], ls[j], ls[k]); res != expected {
+						t.Errorf("Expect T3 %d, but got %d, after %d steps\n", expected, res, numGen)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCalcTMRCA(t *testing.T) {
+	p := runMoran(10, 2000, 1)
+	tmrca := CalcTMRCA(p)
+	t2s := CalcAllT2(p)
+	if len(t2s) != 45 {
+		t.Errorf("Expect %d pairs, but got %d\n", 45, len(t2s))
+	}
+	max := 0.0
+	for _, v := range t2s {
+		if v > max {
+			max = v
+		}
+	}
+	if max != tmrca {
+		t.Errorf("Expect TMRCA %f to be the max of T2 %f\n", tmrca, max)
+	}
+}
@@ -19,21 +19,136 @@ type Config struct {
 			S    float64
 		}
 		Rate float64
+		// Model is the nucleotide substitution model,
+		// which is one of JC69, K80, HKY85 and GTR,
+		// reading the alphabet in the order of A, C, G and T.
+		// Without a name, bases mutate with equal rates.
+		Model struct {
+			Name      string
+			Kappa     float64   // transition/transversion rate ratio.
+			Exchanges []float64 // exchangeabilities of GTR.
+			Freqs     []float64 // equilibrium frequencies.
+		}
+		// Gamma sets the relative rates of the sites,
+		// drawn from a discrete gamma distribution of the shape
+		// with a number of categories (4 by default),
+		// and a proportion of invariant sites.
+		// A zero shape means equal rates among the variable sites.
+		Gamma struct {
+			Shape      float64
+			Categories int
+			Invariant  float64
+		}
+		// DFE sets the rate of mutations on fitness,
+		// which are neutral, deleterious or beneficial with the probabilities,
+		// with gamma-distributed deleterious effects of the shape and mean,
+		// and exponentially distributed beneficial effects of the mean.
+		DFE struct {
+			Rate            float64
+			Neutral         float64
+			Deleterious     float64
+			Beneficial      float64
+			Shape           float64
+			MeanDeleterious float64
+			MeanBeneficial  float64
+		}
 	}
 
+	// Transfers within (In) and into (Out) the population.
+	// If Delta is positive, transfers are accepted with the probability
+	// exp(-d/Delta), where d is the divergence over the fragment,
+	// or over the flanking regions of Seed sites if Seed is positive.
 	Transfer struct {
 		In struct {
 			Rate     float64
 			Fragment int
+			Delta    float64
+			Seed     int
 		}
 		Out struct {
+			Rate float64
+			// Fragment is the mean size of fragments,
+			// which is that of In without a value.
+			Fragment int
+			Delta    float64
+			Seed     int
+		}
+		// Map sets the relative rates of regions along the genome,
+		// which bias where transfers start,
+		// and MapFile is a file of the regions in a BED-like format.
+		// Positions outside the regions have the relative rate 1.
+		Map     []MapRegion
+		MapFile string
+		// Insertion inserts foreign fragments as accessory material,
+		// taken from the sequences in the Pool file in FASTA format,
+		// or from the other populations if there is no pool.
+		Insertion struct {
 			Rate     float64
 			Fragment int
+			Pool     string
 		}
 	}
 
-	SampleMethod  string
+	// Migration sets the rate per genome per generation,
+	// at which genomes are replaced by migrants from the other populations.
+	// Topology is one of island (the default), stepping-stone and ring,
+	// which share the rate among the other populations or the neighbors,
+	// or matrix, with the rates from each population in Matrix.
+	Migration struct {
+		Rate     float64
+		Topology string
+		Matrix   []float64
+	}
+
+	// Indel sets the rate of insertions and deletions,
+	// with the mean length of the fragments,
+	// and the proportion of insertions among them.
+	Indel struct {
+		Rate      float64
+		Fragment  int
+		Insertion float64
+	}
+
+	// Schedule sets the size of the population over generations,
+	// which is one of step, exponential, periodic and table,
+	// or constant without a name.
+	Schedule ScheduleParams
+
+	// SerialTransfer sets a serial-transfer protocol,
+	// which dilutes the population by the Dilution factor,
+	// and grows it back to the carrying Capacity, for a number of Transfers,
+	// sampling SampleSize genomes for the statistics after each transfer.
+	// The Shock is applied before the dilution of the transfers in ShockAt.
+	SerialTransfer struct {
+		Dilution   float64
+		Capacity   int
+		Transfers  int
+		SampleSize int
+		Shock      ShockParams
+		ShockAt    []int
+	}
+
+	// Landscape sets the fitness landscape of the sequences,
+	// which is one of additive, epistatic and nk,
+	// or no landscape without a name.
+	Landscape LandscapeParams
+
+	SampleMethod string
+	// FitnessMap maps fitness to the relative numbers of offspring,
+	// which is one of exponential (the default), linear, multiplicative,
+	// and truncation of the fittest TruncationFraction of the population.
+	FitnessMap         string
+	TruncationFraction float64
+	// FragGenerator is the distribution of fragment sizes,
+	// which is one of constant (the default), exponential, geometric,
+	// gamma, lognormal, uniform and empirical,
+	// with the parameters other than the mean size in FragParams.
 	FragGenerator string
+	FragParams    FragParams
+
+	// ARG enables tracking the ancestry of genome segments,
+	// so that transfers are recorded in local genealogies.
+	ARG bool
 }
 
 func (c *Config) String() string {
@@ -42,10 +157,54 @@ func (c *Config) String() string {
 	fmt.Fprintf(&b, "Genome length: %d\n", c.Length)
 	fmt.Fprintf(&b, "Alphabet: %s\n", string(c.Alphabet))
 	fmt.Fprintf(&b, "Mutation rate: %f\n", c.Mutation.Rate)
+	if c.Mutation.Model.Name != "" {
+		fmt.Fprintf(&b, "Substitution model: %s\n", c.Mutation.Model.Name)
+	}
+	if g := c.Mutation.Gamma; g.Shape > 0 || g.Invariant > 0 {
+		fmt.Fprintf(&b, "Gamma shape: %f\n", g.Shape)
+		fmt.Fprintf(&b, "Proportion of invariant sites: %f\n", g.Invariant)
+	}
+	if d := c.Mutation.DFE; d.Rate > 0 {
+		fmt.Fprintf(&b, "DFE rate: %f\n", d.Rate)
+		fmt.Fprintf(&b, "DFE probabilities: %f neutral, %f deleterious, %f beneficial\n", d.Neutral, d.Deleterious, d.Beneficial)
+	}
+	if s := c.SerialTransfer; s.Transfers > 0 {
+		fmt.Fprintf(&b, "Serial transfers: %d\n", s.Transfers)
+		fmt.Fprintf(&b, "Dilution factor: %f\n", s.Dilution)
+		fmt.Fprintf(&b, "Carrying capacity: %d\n", s.Capacity)
+		if s.Shock.Name != "" {
+			fmt.Fprintf(&b, "Shock: %s at transfers %v\n", s.Shock.Name, s.ShockAt)
+		}
+	}
+	if c.Landscape.Name != "" {
+		fmt.Fprintf(&b, "Fitness landscape: %s\n", c.Landscape.Name)
+	}
+	if c.Schedule.Name != "" {
+		fmt.Fprintf(&b, "Size schedule: %s\n", c.Schedule.Name)
+	}
+	if c.FitnessMap != "" {
+		fmt.Fprintf(&b, "Fitness map: %s\n", c.FitnessMap)
+	}
 	fmt.Fprintf(&b, "Transfer rate (in): %f\n", c.Transfer.In.Rate)
 	fmt.Fprintf(&b, "Transfer fragment (in): %d\n", c.Transfer.In.Fragment)
 	fmt.Fprintf(&b, "Transfer rate (out): %f\n", c.Transfer.Out.Rate)
 	fmt.Fprintf(&b, "Transfer fragment (out): %d\n", c.Transfer.Out.Fragment)
+	if c.Transfer.In.Delta > 0 || c.Transfer.Out.Delta > 0 {
+		fmt.Fprintf(&b, "Transfer delta (in): %f\n", c.Transfer.In.Delta)
+		fmt.Fprintf(&b, "Transfer delta (out): %f\n", c.Transfer.Out.Delta)
+	}
+	if c.Transfer.Insertion.Rate > 0 {
+		fmt.Fprintf(&b, "Insertion rate: %f\n", c.Transfer.Insertion.Rate)
+		fmt.Fprintf(&b, "Insertion fragment: %d\n", c.Transfer.Insertion.Fragment)
+	}
+	if c.Migration.Rate > 0 || len(c.Migration.Matrix) > 0 {
+		fmt.Fprintf(&b, "Migration rate: %f\n", c.Migration.Rate)
+		fmt.Fprintf(&b, "Migration topology: %s\n", c.Migration.Topology)
+	}
+	if c.Indel.Rate > 0 {
+		fmt.Fprintf(&b, "Indel rate: %f\n", c.Indel.Rate)
+		fmt.Fprintf(&b, "Indel fragment: %d\n", c.Indel.Fragment)
+	}
 
 	return b.String()
 }
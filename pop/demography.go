@@ -0,0 +1,233 @@
+package pop
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Split partitions the genomes of the population at random
+// into daughter populations of the sizes,
+// in which the genomes keep their lineages and ancestries,
+// and the clock of the population.
+// Genomes beyond the total size of the daughters are left out.
+// The daughters should evolve on a common clock (see SyncClocks),
+// so that their lineages stay on the same time scale.
+func Split(p *Pop, sizes []int, src rand.Source) ([]*Pop, error) {
+	total := 0
+	for _, n := range sizes {
+		if n < 1 {
+			return nil, fmt.Errorf("split requires positive sizes of daughters, but got %d", n)
+		}
+		total += n
+	}
+	if total > p.Size() {
+		return nil, fmt.Errorf("split requires at most %d genomes in the daughters, but got %d", p.Size(), total)
+	}
+	if len(p.Lineages) < p.Size() {
+		p.NewLineages()
+	}
+
+	indices := rand.New(src).Perm(p.Size())
+	daughters := []*Pop{}
+	for _, n := range sizes {
+		d := p.daughter()
+		for _, i := range indices[:n] {
+			d.Genomes = append(d.Genomes, p.Genomes[i])
+			d.Lineages = append(d.Lineages, p.Lineages[i])
+			if p.Ancestries != nil {
+				d.Ancestries = append(d.Ancestries, p.Ancestries[i])
+			}
+		}
+		d.TargetSize = n
+		indices = indices[n:]
+		daughters = append(daughters, d)
+	}
+	return daughters, nil
+}
+
+// daughter returns an empty population with the settings of the population,
+// and a copy of its mutations.
+func (p *Pop) daughter() *Pop {
+	d := New()
+	d.Circled = p.Circled
+	d.Ancestor = p.Ancestor
+	d.Mutations = append([]Mutation{}, p.Mutations...)
+	d.RecordMutations = p.RecordMutations
+	d.NumGeneration = p.NumGeneration
+	return d
+}
+
+// Merge merges the populations into one,
+// in which the genomes keep their lineages and ancestries.
+// If any of the populations is in the ARG mode, the merged one is in it too.
+// The populations should share a clock (see SyncClocks),
+// since their lineages are merged by the times of their births.
+// The generation of the merged population is the latest of them.
+func Merge(pops ...*Pop) *Pop {
+	if len(pops) == 0 {
+		return New()
+	}
+	m := pops[0].daughter()
+	m.Mutations = nil
+	arg := false
+	for _, p := range pops {
+		if p.Ancestries != nil {
+			arg = true
+		}
+	}
+	seen := make(map[Mutation]bool)
+	for _, p := range pops {
+		if len(p.Lineages) < p.Size() {
+			p.NewLineages()
+		}
+		m.Genomes = append(m.Genomes, p.Genomes...)
+		m.Lineages = append(m.Lineages, p.Lineages...)
+		if arg {
+			if p.Ancestries == nil {
+				for _, l := range p.Lineages {
+					m.Ancestries = append(m.Ancestries, NewAncestry(p.RefLength(), l))
+				}
+			} else {
+				m.Ancestries = append(m.Ancestries, p.Ancestries...)
+			}
+		}
+		// daughters of a split share the mutations before it.
+		for _, mut := range p.Mutations {
+			if !seen[mut] {
+				seen[mut] = true
+				m.Mutations = append(m.Mutations, mut)
+			}
+		}
+		m.RecordMutations = m.RecordMutations || p.RecordMutations
+		m.Transfer.Attempted += p.Transfer.Attempted
+		m.Transfer.Accepted += p.Transfer.Accepted
+		if p.NumGeneration > m.NumGeneration {
+			m.NumGeneration = p.NumGeneration
+		}
+	}
+	m.TargetSize = m.Size()
+	return m
+}
+
+// Demographic events.
+const (
+	SplitEvent = "split"
+	MergeEvent = "merge"
+)
+
+// DemographicEvent splits or merges populations at a time in generations.
+type DemographicEvent struct {
+	Time float64
+	Type string
+	// Pops are the indices of the population to split, or of those to merge.
+	Pops []int
+	// Sizes are the sizes of the daughters of a split,
+	// which are halves of the population by default.
+	Sizes []int
+	// Schedules are the size schedules of the new populations,
+	// which keep their sizes by default.
+	Schedules []ScheduleParams
+}
+
+// Apply applies the event to the populations with their configs,
+// and returns the new lists of populations and configs.
+// The daughters of a split take the place of the population with copies of its config,
+// and the population merged takes the place of the first of the populations in order,
+// with the config of the first of them in the event.
+// The configs of the new populations take their sizes and schedules.
+func (e DemographicEvent) Apply(pops []*Pop, configs []Config, src rand.Source) ([]*Pop, []Config, error) {
+	for _, i := range e.Pops {
+		if i < 0 || i >= len(pops) {
+			return nil, nil, fmt.Errorf("%s event refers to population %d of %d", e.Type, i, len(pops))
+		}
+	}
+
+	switch e.Type {
+	case SplitEvent:
+		if len(e.Pops) != 1 {
+			return nil, nil, fmt.Errorf("split event requires one population, but got %d", len(e.Pops))
+		}
+		i := e.Pops[0]
+		sizes := e.Sizes
+		if len(sizes) == 0 {
+			sizes = []int{pops[i].Size() / 2, pops[i].Size() - pops[i].Size()/2}
+		}
+		daughters, err := Split(pops[i], sizes, src)
+		if err != nil {
+			return nil, nil, err
+		}
+		newPops := append(append(append([]*Pop{}, pops[:i]...), daughters...), pops[i+1:]...)
+		newConfigs := append([]Config{}, configs[:i]...)
+		for k, d := range daughters {
+			c := configs[i]
+			c.Size = d.Size()
+			c.Schedule = e.schedule(k)
+			newConfigs = append(newConfigs, c)
+		}
+		newConfigs = append(newConfigs, configs[i+1:]...)
+		return newPops, newConfigs, nil
+	case MergeEvent:
+		if len(e.Pops) < 2 {
+			return nil, nil, fmt.Errorf("merge event requires at least two populations, but got %d", len(e.Pops))
+		}
+		first := e.Pops[0]
+		merging := make(map[int]bool)
+		merged := []*Pop{}
+		for _, i := range e.Pops {
+			if merging[i] {
+				return nil, nil, fmt.Errorf("merge event refers to population %d twice", i)
+			}
+			merging[i] = true
+			merged = append(merged, pops[i])
+			if i < first {
+				first = i
+			}
+		}
+		m := Merge(merged...)
+		c := configs[e.Pops[0]]
+		c.Size = m.Size()
+		c.Schedule = e.schedule(0)
+		newPops, newConfigs := []*Pop{}, []Config{}
+		for i := range pops {
+			if i == first {
+				newPops = append(newPops, m)
+				newConfigs = append(newConfigs, c)
+			} else if !merging[i] {
+				newPops = append(newPops, pops[i])
+				newConfigs = append(newConfigs, configs[i])
+			}
+		}
+		return newPops, newConfigs, nil
+	}
+	return nil, nil, fmt.Errorf("unknown demographic event %s", e.Type)
+}
+
+// schedule returns the size schedule of the k-th new population.
+func (e DemographicEvent) schedule(k int) ScheduleParams {
+	if k < len(e.Schedules) {
+		return e.Schedules[k]
+	}
+	return ScheduleParams{}
+}
+
+// DemographicRecord logs a demographic event applied at a time,
+// with the sizes of the populations after it.
+type DemographicRecord struct {
+	Time  float64
+	Event DemographicEvent
+	Sizes []int
+}
+
+func (r DemographicRecord) String() string {
+	return fmt.Sprintf("%s of populations %v at generation %g, into populations of sizes %v", r.Event.Type, r.Event.Pops, r.Time, r.Sizes)
+}
+
+// Scenario is a simulation of populations with demographic events,
+// over the number of generations,
+// which is reproducible from the Seed of the random numbers if it is not 0.
+type Scenario struct {
+	Populations []Config
+	Events      []DemographicEvent
+	Generations float64
+	Seed        int64
+}
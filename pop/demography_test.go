@@ -0,0 +1,115 @@
+package pop
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSplitMerge(t *testing.T) {
+	src := rand.NewSource(1)
+	p := New()
+	NewRandomPopGenerator(rand.New(src), 20, 10, []byte("ACGT")).Operate(p)
+	p.EnableARG()
+	moran := NewMoranSampler(src)
+	for i := 0; i < 100; i++ {
+		moran.Operate(p)
+	}
+
+	lineages := make(map[*Lineage]bool)
+	for _, l := range p.Lineages {
+		lineages[l] = true
+	}
+	if _, err := Split(p, []int{15, 10}, src); err == nil {
+		t.Errorf("Expect an error for daughters larger than the population\n")
+	}
+	daughters, err := Split(p, []int{12, 8}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[*Lineage]bool)
+	for k, d := range daughters {
+		if d.Size() != []int{12, 8}[k] || len(d.Ancestries) != d.Size() || d.NumGeneration != p.NumGeneration {
+			t.Errorf("Expect daughter %d of size %d at generation %d\n", k, d.Size(), p.NumGeneration)
+		}
+		for _, l := range d.Lineages {
+			if !lineages[l] || seen[l] {
+				t.Errorf("Expect the lineages to be partitioned among the daughters\n")
+			}
+			seen[l] = true
+		}
+	}
+
+	// the daughters evolve on a common clock.
+	numGeneration := p.NumGeneration
+	for i := 0; i < 50; i++ {
+		moran.Operate(daughters[i%2])
+		SyncClocks(daughters...)
+	}
+	m := Merge(daughters...)
+	if m.Size() != 20 || len(m.Lineages) != 20 || len(m.Ancestries) != 20 {
+		t.Errorf("Expect a merged population of 20 genomes\n")
+	}
+	if m.NumGeneration != numGeneration+50 {
+		t.Errorf("Expect the generation %d, but got %d\n", numGeneration+50, m.NumGeneration)
+	}
+	// every lineage of the merged population is younger than its parent.
+	check := func(l *Lineage) {
+		for ; l != nil && l.Parent != nil; l = l.Parent {
+			if l.Parent.BirthTime > l.BirthTime || l.BirthTime > m.NumGeneration {
+				t.Fatalf("Expect a parent born at %d before its child born at %d\n", l.Parent.BirthTime, l.BirthTime)
+			}
+		}
+	}
+	for i, l := range m.Lineages {
+		check(l)
+		for _, s := range m.Ancestries[i] {
+			check(s.Lineage)
+		}
+	}
+	// the genealogy of the merged population goes back through the split.
+	if tmrca := CalcTMRCA(m); tmrca <= 50 {
+		t.Errorf("Expect a TMRCA before the split, but got %f\n", tmrca)
+	}
+}
+
+func TestDemographicEvent(t *testing.T) {
+	src := rand.NewSource(1)
+	pops := []*Pop{}
+	configs := []Config{}
+	for i := 0; i < 2; i++ {
+		p := New()
+		NewRandomPopGenerator(rand.New(src), 10, 10, []byte("ACGT")).Operate(p)
+		c := Config{Size: 10, Length: 10}
+		c.Mutation.Rate = float64(i)
+		pops = append(pops, p)
+		configs = append(configs, c)
+	}
+
+	configs[0].Schedule.Name = "periodic"
+	split := DemographicEvent{Type: SplitEvent, Pops: []int{0}, Sizes: []int{3, 4, 3}}
+	split.Schedules = []ScheduleParams{{Name: "exponential"}}
+	pops, configs, err := split.Apply(pops, configs, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pops) != 4 || len(configs) != 4 || pops[1].Size() != 4 || configs[1].Size != 4 || configs[3].Mutation.Rate != 1 {
+		t.Errorf("Expect the daughters in place of the population\n")
+	}
+	if configs[0].Schedule.Name != "exponential" || configs[1].Schedule.Name != "" {
+		t.Errorf("Expect the schedules of the event for the daughters\n")
+	}
+
+	merge := DemographicEvent{Type: MergeEvent, Pops: []int{3, 0}}
+	pops, configs, err = merge.Apply(pops, configs, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pops) != 3 || pops[0].Size() != 13 || configs[0].Size != 13 || configs[0].Mutation.Rate != 1 {
+		t.Errorf("Expect the merged population in place of the first one\n")
+	}
+
+	bad := DemographicEvent{Type: MergeEvent, Pops: []int{0, 5}}
+	if _, _, err := bad.Apply(pops, configs, src); err == nil {
+		t.Errorf("Expect an error for an unknown population\n")
+	}
+}
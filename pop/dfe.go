@@ -0,0 +1,140 @@
+package pop
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// MutationClass is the class of the fitness effect of a mutation.
+type MutationClass int
+
+// Classes of mutations.
+const (
+	NeutralMutation MutationClass = iota
+	DeleteriousMutation
+	BeneficialMutation
+)
+
+// MutationCounts stores the numbers of mutations of each class carried by a genome.
+type MutationCounts struct {
+	Neutral     int
+	Deleterious int
+	Beneficial  int
+}
+
+// Add increases the number of mutations of the class by one.
+func (c *MutationCounts) Add(class MutationClass) {
+	switch class {
+	case NeutralMutation:
+		c.Neutral++
+	case DeleteriousMutation:
+		c.Deleterious++
+	case BeneficialMutation:
+		c.Beneficial++
+	}
+}
+
+// DFE is a distribution of fitness effects,
+// which is a mixture of neutral mutations,
+// deleterious mutations with gamma-distributed effects,
+// and beneficial mutations with exponentially distributed effects.
+type DFE struct {
+	// probabilities of the classes.
+	Neutral     float64
+	Deleterious float64
+	Beneficial  float64
+	// Shape and MeanDeleterious are the shape and the mean
+	// of the gamma distribution of deleterious effects.
+	Shape           float64
+	MeanDeleterious float64
+	// MeanBeneficial is the mean of beneficial effects.
+	MeanBeneficial float64
+
+	r *rand.Rand
+}
+
+// NewDFE returns a new DFE, given the probabilities of the classes,
+// which are normalized to sum to one.
+func NewDFE(neutral, deleterious, beneficial, shape, meanDeleterious, meanBeneficial float64, src rand.Source) (*DFE, error) {
+	total := neutral + deleterious + beneficial
+	if neutral < 0 || deleterious < 0 || beneficial < 0 || total <= 0 {
+		return nil, fmt.Errorf("DFE requires non-negative probabilities with a positive sum, but got %f, %f and %f", neutral, deleterious, beneficial)
+	}
+	if deleterious > 0 && (shape <= 0 || meanDeleterious <= 0) {
+		return nil, fmt.Errorf("DFE requires a positive shape and mean of deleterious effects, but got %f and %f", shape, meanDeleterious)
+	}
+	if beneficial > 0 && meanBeneficial <= 0 {
+		return nil, fmt.Errorf("DFE requires a positive mean of beneficial effects, but got %f", meanBeneficial)
+	}
+	d := DFE{
+		Neutral:         neutral / total,
+		Deleterious:     deleterious / total,
+		Beneficial:      beneficial / total,
+		Shape:           shape,
+		MeanDeleterious: meanDeleterious,
+		MeanBeneficial:  meanBeneficial,
+		r:               rand.New(src),
+	}
+	return &d, nil
+}
+
+// Sample returns the class of a random mutation and its effect on fitness,
+// which is negative for deleterious mutations.
+func (d *DFE) Sample() (class MutationClass, delta float64) {
+	u := d.r.Float64()
+	switch {
+	case u < d.Neutral:
+		return NeutralMutation, 0
+	case u < d.Neutral+d.Deleterious:
+		return DeleteriousMutation, -gammaVariate(d.r, d.Shape) * d.MeanDeleterious / d.Shape
+	}
+	return BeneficialMutation, d.r.ExpFloat64() * d.MeanBeneficial
+}
+
+// MutationLoad summarizes the mutations carried by the genomes of a population.
+type MutationLoad struct {
+	// mean numbers of mutations per genome.
+	Neutral     float64
+	Deleterious float64
+	Beneficial  float64
+	// MinDeleterious is the number of deleterious mutations
+	// of the least-loaded genomes, which clicks up with Muller's ratchet.
+	MinDeleterious int
+	// LeastLoaded is the fraction of genomes in the least-loaded class.
+	LeastLoaded float64
+}
+
+// CalcMutationLoad calculates the mutation load of the population.
+func CalcMutationLoad(p *Pop) MutationLoad {
+	var l MutationLoad
+	if p.Size() == 0 {
+		return l
+	}
+	l.MinDeleterious = math.MaxInt32
+	counts := []MutationCounts{}
+	for _, g := range p.Genomes {
+		var c MutationCounts
+		if fg, ok := g.(FitnessUpdater); ok {
+			c = fg.MutationCounts()
+		}
+		counts = append(counts, c)
+		l.Neutral += float64(c.Neutral)
+		l.Deleterious += float64(c.Deleterious)
+		l.Beneficial += float64(c.Beneficial)
+		if c.Deleterious < l.MinDeleterious {
+			l.MinDeleterious = c.Deleterious
+		}
+	}
+	for _, c := range counts {
+		if c.Deleterious == l.MinDeleterious {
+			l.LeastLoaded++
+		}
+	}
+	n := float64(p.Size())
+	l.Neutral /= n
+	l.Deleterious /= n
+	l.Beneficial /= n
+	l.LeastLoaded /= n
+	return l
+}
@@ -0,0 +1,89 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDFE(t *testing.T) {
+	src := rand.NewSource(1)
+	if _, err := NewDFE(0.5, 0.5, 0, 0, 0.1, 0, src); err == nil {
+		t.Errorf("Expect an error for a zero shape of deleterious effects\n")
+	}
+	dfe, err := NewDFE(2, 6, 2, 0.5, 0.01, 0.02, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dfe.Neutral != 0.2 || dfe.Deleterious != 0.6 || dfe.Beneficial != 0.2 {
+		t.Errorf("Expect normalized probabilities, but got %f, %f and %f\n", dfe.Neutral, dfe.Deleterious, dfe.Beneficial)
+	}
+
+	n := 100000
+	var counts MutationCounts
+	sumDel, sumBen := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		class, delta := dfe.Sample()
+		counts.Add(class)
+		switch class {
+		case NeutralMutation:
+			if delta != 0 {
+				t.Errorf("Expect no effect of neutral mutations, but got %f\n", delta)
+			}
+		case DeleteriousMutation:
+			if delta > 0 {
+				t.Errorf("Expect negative effects of deleterious mutations, but got %f\n", delta)
+			}
+			sumDel += delta
+		case BeneficialMutation:
+			if delta < 0 {
+				t.Errorf("Expect positive effects of beneficial mutations, but got %f\n", delta)
+			}
+			sumBen += delta
+		}
+	}
+	if f := float64(counts.Deleterious) / float64(n); math.Abs(f-0.6) > 0.01 {
+		t.Errorf("Expect deleterious fraction 0.6, but got %f\n", f)
+	}
+	if m := -sumDel / float64(counts.Deleterious); math.Abs(m-0.01) > 0.0005 {
+		t.Errorf("Expect mean deleterious effect 0.01, but got %f\n", m)
+	}
+	if m := sumBen / float64(counts.Beneficial); math.Abs(m-0.02) > 0.001 {
+		t.Errorf("Expect mean beneficial effect 0.02, but got %f\n", m)
+	}
+}
+
+func TestDFEMutator(t *testing.T) {
+	src := rand.NewSource(1)
+	p := New()
+	NewRandomPopGenerator(rand.New(src), 20, 10, []byte("ACGT")).Operate(p)
+	dfe, err := NewDFE(0, 1, 0, 1, 0.1, 0, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mutator := NewDFEMutator(dfe, src)
+	for i := 0; i < 100; i++ {
+		mutator.Operate(p)
+	}
+	total := 0
+	for _, g := range p.Genomes {
+		ng := g.(*NeutralGenome)
+		total += ng.Counts.Deleterious
+		if ng.Counts.Deleterious > 0 && ng.Fitness() >= 0 {
+			t.Errorf("Expect deleterious mutations to decrease fitness\n")
+		}
+		if c := g.Copy().(*NeutralGenome).Counts; c != ng.Counts {
+			t.Errorf("Expect copies to keep the mutation counts\n")
+		}
+	}
+	if total != 100 {
+		t.Errorf("Expect 100 deleterious mutations, but got %d\n", total)
+	}
+	l := CalcMutationLoad(p)
+	if l.Deleterious != 5 || l.Neutral != 0 {
+		t.Errorf("Expect 5 deleterious mutations per genome, but got %f\n", l.Deleterious)
+	}
+	if l.LeastLoaded <= 0 || l.LeastLoaded > 1 {
+		t.Errorf("Expect the least-loaded fraction in (0, 1], but got %f\n", l.LeastLoaded)
+	}
+}
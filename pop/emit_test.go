@@ -8,7 +8,7 @@ import (
 
 func TestEmit(t *testing.T) {
 	tolerance := 1e-3
-	r := rand.New(rand.NewSource(1))
+	rw := NewRouletteWheel(rand.NewSource(1))
 	events := []*Event{
 		&Event{Rate: 0.5},
 		&Event{Rate: 0.9},
@@ -20,7 +20,7 @@ func TestEmit(t *testing.T) {
 
 	numEvents := 1000000
 	for i := 0; i < numEvents; i++ {
-		e := Emit(events, r)
+		e := Emit(events, rw)
 		eventCountMap[e.Rate]++
 	}
 
@@ -6,3 +6,25 @@ func Evolve(eventChan chan *Event) {
 		e.Ops.Operate(e.Pop)
 	}
 }
+
+// EvolveApply evolves populations by the channel of events,
+// in which Appliers return errors instead of panicking,
+// and returns the first error, after which the channel is no longer read.
+func EvolveApply(eventChan chan *Event) error {
+	for e := range eventChan {
+		if err := Apply(e.Ops, e.Pop); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply operates on the population,
+// and returns the error of the operator if it is an Applier.
+func Apply(op Operator, p *Pop) error {
+	if a, ok := op.(Applier); ok {
+		return a.Apply(p)
+	}
+	op.Operate(p)
+	return nil
+}
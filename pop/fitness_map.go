@@ -0,0 +1,123 @@
+package pop
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// FitnessMap maps the fitness of the genomes of a population
+// to their relative numbers of offspring, which samplers share.
+type FitnessMap interface {
+	Weights(p *Pop) []float64
+}
+
+// fitnessWeights returns the weights given by the fitness map,
+// which is ExponentialMap if it is nil.
+func fitnessWeights(m FitnessMap, p *Pop) []float64 {
+	if m == nil {
+		m = ExponentialMap{}
+	}
+	return m.Weights(p)
+}
+
+// ExponentialMap gives the weights exp(f - meanFit),
+// which treats fitness as the logarithm of the growth rate.
+type ExponentialMap struct{}
+
+func (ExponentialMap) Weights(p *Pop) []float64 {
+	meanFit := p.MeanFit()
+	weights := make([]float64, p.Size())
+	for i, g := range p.Genomes {
+		weights[i] = math.Exp(g.Fitness() - meanFit)
+	}
+	return weights
+}
+
+// LinearMap gives the weights 1 + s, where s is the fitness,
+// and genomes with s < -1 have no offspring.
+type LinearMap struct{}
+
+func (LinearMap) Weights(p *Pop) []float64 {
+	weights := make([]float64, p.Size())
+	for i, g := range p.Genomes {
+		weights[i] = math.Max(0, 1+g.Fitness())
+	}
+	return weights
+}
+
+// MultiplicativeGenome is a genome which keeps the product of 1 + s
+// over the effects s of its mutations.
+type MultiplicativeGenome interface {
+	Genome
+	MultiplicativeFitness() float64
+}
+
+// MultiplicativeMap gives the weights of the products of 1 + s
+// over the effects s of the mutations of the genomes,
+// or exp(f) of those not implementing MultiplicativeGenome.
+type MultiplicativeMap struct{}
+
+func (MultiplicativeMap) Weights(p *Pop) []float64 {
+	weights := make([]float64, p.Size())
+	for i, g := range p.Genomes {
+		if mg, ok := g.(MultiplicativeGenome); ok {
+			weights[i] = mg.MultiplicativeFitness()
+		} else {
+			weights[i] = math.Exp(g.Fitness())
+		}
+	}
+	return weights
+}
+
+// TruncationMap gives the weight 1 to the fittest genomes,
+// making up the fraction of the population, and 0 to the others.
+// Genomes as fit as the least fit of them are included.
+type TruncationMap struct {
+	Fraction float64
+}
+
+func (t TruncationMap) Weights(p *Pop) []float64 {
+	weights := make([]float64, p.Size())
+	if p.Size() == 0 {
+		return weights
+	}
+	fits := make([]float64, p.Size())
+	for i, g := range p.Genomes {
+		fits[i] = g.Fitness()
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(fits)))
+	n := int(math.Ceil(t.Fraction * float64(p.Size())))
+	if n < 1 {
+		n = 1
+	} else if n > p.Size() {
+		n = p.Size()
+	}
+	threshold := fits[n-1]
+	for i, g := range p.Genomes {
+		if g.Fitness() >= threshold {
+			weights[i] = 1
+		}
+	}
+	return weights
+}
+
+// NewFitnessMap returns a fitness map by its name,
+// which is one of exponential (the default), linear, multiplicative,
+// and truncation of the fraction of the fittest genomes.
+func NewFitnessMap(name string, fraction float64) (FitnessMap, error) {
+	switch name {
+	case "", "exponential":
+		return ExponentialMap{}, nil
+	case "linear":
+		return LinearMap{}, nil
+	case "multiplicative":
+		return MultiplicativeMap{}, nil
+	case "truncation":
+		if fraction <= 0 || fraction > 1 {
+			return nil, fmt.Errorf("truncation requires a fraction in (0, 1], but got %f", fraction)
+		}
+		return TruncationMap{Fraction: fraction}, nil
+	}
+	return nil, fmt.Errorf("unknown fitness map %s", name)
+}
@@ -0,0 +1,157 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func fitnessPop(fits ...float64) *Pop {
+	p := New()
+	for _, f := range fits {
+		g := &NeutralGenome{Sequence: ByteSequence("A")}
+		g.UpdateFitness(f, BeneficialMutation)
+		p.Genomes = append(p.Genomes, g)
+	}
+	p.TargetSize = p.Size()
+	p.NewLineages()
+	return p
+}
+
+func TestFitnessMaps(t *testing.T) {
+	p := fitnessPop(0, 0.5, -2)
+	tests := []struct {
+		m       FitnessMap
+		weights []float64
+	}{
+		{ExponentialMap{}, []float64{math.Exp(0.5), math.Exp(1), math.Exp(-1.5)}},
+		{LinearMap{}, []float64{1, 1.5, 0}},
+		{MultiplicativeMap{}, []float64{1, 1.5, 0}},
+		{TruncationMap{Fraction: 0.5}, []float64{1, 1, 0}},
+		{TruncationMap{Fraction: 0.1}, []float64{0, 1, 0}},
+	}
+	for _, test := range tests {
+		weights := test.m.Weights(p)
+		for i, w := range weights {
+			if math.Abs(w-test.weights[i]) > 1e-9 {
+				t.Errorf("Expect weights %v from %T, but got %v\n", test.weights, test.m, weights)
+				break
+			}
+		}
+	}
+
+	// multiplicative fitness compounds the effects of mutations.
+	g := &NeutralGenome{}
+	g.UpdateFitness(0.1, BeneficialMutation)
+	g.UpdateFitness(0.1, BeneficialMutation)
+	if f := g.Copy().(*NeutralGenome).MultiplicativeFitness(); math.Abs(f-1.21) > 1e-9 {
+		t.Errorf("Expect multiplicative fitness 1.21, but got %f\n", f)
+	}
+
+	if _, err := NewFitnessMap("truncation", 0); err == nil {
+		t.Errorf("Expect an error for a zero truncation fraction\n")
+	}
+	if _, err := NewFitnessMap("unknown", 0); err == nil {
+		t.Errorf("Expect an error for an unknown fitness map\n")
+	}
+}
+
+func TestSamplerFitnessMap(t *testing.T) {
+	src := rand.NewSource(1)
+	// only the fittest genome reproduces under truncation selection.
+	samplers := []Sampler{NewWrightFisherSampler(src), NewLinearSelectionSampler(src)}
+	samplers[0].(*WrightFisherSampler).Fitness = TruncationMap{Fraction: 0.01}
+	samplers[1].(*LinearSelectionSampler).Fitness = TruncationMap{Fraction: 0.01}
+	for _, s := range samplers {
+		p := fitnessPop(0, 0, 1, 0, 0)
+		s.Start()
+		s.Operate(p)
+		s.Wait()
+		for _, g := range p.Genomes {
+			if g.Fitness() != 1 {
+				t.Errorf("Expect offspring of the fittest genome from %T, but got fitness %f\n", s, g.Fitness())
+			}
+		}
+	}
+
+	m := NewMoranSampler(src)
+	m.Fitness = TruncationMap{Fraction: 0.01}
+	p := fitnessPop(0, 0, 1, 0, 0)
+	for i := 0; i < 100; i++ {
+		m.Operate(p)
+	}
+	for _, g := range p.Genomes {
+		if g.Fitness() != 1 {
+			t.Errorf("Expect the fittest genome to take over, but got fitness %f\n", g.Fitness())
+		}
+	}
+}
+
+func TestSamplerDefaultFitnessMap(t *testing.T) {
+	src := rand.NewSource(1)
+	fits := make([]float64, 100)
+	for i := 50; i < 100; i++ {
+		fits[i] = 5
+	}
+	// samplers without a fitness map select by ExponentialMap.
+	samplers := []Sampler{NewWrightFisherSampler(src), NewLinearSelectionSampler(src)}
+	for _, s := range samplers {
+		p := fitnessPop(fits...)
+		s.Start()
+		s.Operate(p)
+		s.Wait()
+		fitter := 0
+		for _, g := range p.Genomes {
+			if g.Fitness() == 5 {
+				fitter++
+			}
+		}
+		if float64(fitter) < 0.95*float64(p.Size()) {
+			t.Errorf("Expect offspring of the fitter genomes from %T, but got %d of %d\n", s, fitter, p.Size())
+		}
+	}
+}
+
+func TestSamplerZeroWeights(t *testing.T) {
+	src := rand.NewSource(1)
+	fits := make([]float64, 50)
+	for i := range fits {
+		fits[i] = -2
+	}
+	// no genome has offspring under LinearMap,
+	// so that parents are chosen uniformly.
+	wf := NewWrightFisherSampler(src)
+	wf.Fitness = LinearMap{}
+	p := fitnessPop(fits...)
+	parents := make(map[*Lineage]bool)
+	wf.Start()
+	wf.Operate(p)
+	wf.Wait()
+	for _, l := range p.Lineages {
+		parents[l.Parent] = true
+	}
+	if len(parents) < 10 {
+		t.Errorf("Expect uniform parents from %T, but got %d distinct parents\n", wf, len(parents))
+	}
+
+	moran := NewMoranSampler(src)
+	moran.Fitness = LinearMap{}
+	p = fitnessPop(fits...)
+	// indices of the parents in the steps.
+	indices := make(map[int]bool)
+	for i := 0; i < 50; i++ {
+		old := make(map[*Lineage]int)
+		for k, l := range p.Lineages {
+			old[l] = k
+		}
+		moran.Operate(p)
+		for _, l := range p.Lineages {
+			if _, found := old[l]; !found {
+				indices[old[l.Parent]] = true
+			}
+		}
+	}
+	if len(indices) < 10 {
+		t.Errorf("Expect uniform parents from %T, but got %d distinct parents\n", moran, len(indices))
+	}
+}
@@ -0,0 +1,227 @@
+package pop
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GeometricFrag generates fragment sizes from a geometric distribution
+// on 1, 2, ..., with the success probability P and the mean 1/P.
+type GeometricFrag struct {
+	P float64
+	r *rand.Rand
+}
+
+// NewGeometricFrag returns a new GeometricFrag.
+func NewGeometricFrag(p float64, src rand.Source) *GeometricFrag {
+	return &GeometricFrag{P: p, r: rand.New(src)}
+}
+
+func (g *GeometricFrag) Size() int {
+	if g.P >= 1 {
+		return 1
+	}
+	u := 1 - g.r.Float64() // in (0, 1]
+	return int(math.Floor(math.Log(u)/math.Log(1-g.P))) + 1
+}
+
+// GammaFrag generates fragment sizes from a gamma distribution
+// with the shape and scale.
+type GammaFrag struct {
+	Shape float64
+	Scale float64
+	r     *rand.Rand
+}
+
+// NewGammaFrag returns a new GammaFrag.
+func NewGammaFrag(shape, scale float64, src rand.Source) *GammaFrag {
+	return &GammaFrag{Shape: shape, Scale: scale, r: rand.New(src)}
+}
+
+func (g *GammaFrag) Size() int {
+	return int(gammaVariate(g.r, g.Shape) * g.Scale)
+}
+
+// gammaVariate returns a random number from the gamma distribution
+// of the shape and unit scale, by the method of Marsaglia and Tsang (2000).
+func gammaVariate(r *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		// boost the shape, and scale the result by U^(1/shape).
+		return gammaVariate(r, shape+1) * math.Pow(1-r.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := r.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
+// LogNormalFrag generates fragment sizes from a log-normal distribution,
+// whose logarithm has the mean Mu and the standard deviation Sigma.
+type LogNormalFrag struct {
+	Mu    float64
+	Sigma float64
+	r     *rand.Rand
+}
+
+// NewLogNormalFrag returns a new LogNormalFrag.
+func NewLogNormalFrag(mu, sigma float64, src rand.Source) *LogNormalFrag {
+	return &LogNormalFrag{Mu: mu, Sigma: sigma, r: rand.New(src)}
+}
+
+func (l *LogNormalFrag) Size() int {
+	return int(math.Exp(l.Mu + l.Sigma*l.r.NormFloat64()))
+}
+
+// UniformFrag generates fragment sizes uniformly in [Min, Max].
+type UniformFrag struct {
+	Min, Max int
+	r        *rand.Rand
+}
+
+// NewUniformFrag returns a new UniformFrag.
+func NewUniformFrag(min, max int, src rand.Source) *UniformFrag {
+	return &UniformFrag{Min: min, Max: max, r: rand.New(src)}
+}
+
+func (u *UniformFrag) Size() int {
+	return u.Min + u.r.Intn(u.Max-u.Min+1)
+}
+
+// EmpiricalFrag generates fragment sizes from a histogram of observed sizes.
+type EmpiricalFrag struct {
+	Lengths []int
+	Weights []float64
+
+	cum []float64 // cumulative weights.
+	r   *rand.Rand
+}
+
+// NewEmpiricalFrag returns a new EmpiricalFrag,
+// given the sizes and their weights.
+func NewEmpiricalFrag(lengths []int, weights []float64, src rand.Source) (*EmpiricalFrag, error) {
+	if len(lengths) == 0 || len(lengths) != len(weights) {
+		return nil, fmt.Errorf("empirical fragment sizes require the same positive numbers of lengths and weights, but got %d and %d", len(lengths), len(weights))
+	}
+	e := EmpiricalFrag{Lengths: lengths, Weights: weights, r: rand.New(src)}
+	total := 0.0
+	for i, w := range weights {
+		if w < 0 || lengths[i] < 0 {
+			return nil, fmt.Errorf("empirical fragment sizes require non-negative lengths and weights")
+		}
+		total += w
+		e.cum = append(e.cum, total)
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("empirical fragment sizes require a positive total weight")
+	}
+	return &e, nil
+}
+
+// ReadEmpiricalFrag reads a histogram of fragment sizes,
+// in which each line has a size and its count,
+// or only a size, counted once.
+// Empty lines and lines starting with # are ignored.
+func ReadEmpiricalFrag(r io.Reader, src rand.Source) (*EmpiricalFrag, error) {
+	lengths := []int{}
+	weights := []float64{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		length, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		weight := 1.0
+		if len(fields) > 1 {
+			weight, err = strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		lengths = append(lengths, length)
+		weights = append(weights, weight)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewEmpiricalFrag(lengths, weights, src)
+}
+
+func (e *EmpiricalFrag) Size() int {
+	v := e.r.Float64() * e.cum[len(e.cum)-1]
+	i := sort.Search(len(e.cum), func(i int) bool { return e.cum[i] > v })
+	return e.Lengths[i]
+}
+
+// FragParams stores the parameters of fragment size distributions,
+// other than the mean size.
+type FragParams struct {
+	Shape    float64 // shape of gamma.
+	Sigma    float64 // standard deviation of the logarithm of log-normal.
+	Min, Max int     // range of uniform.
+	File     string  // histogram file of empirical.
+}
+
+// NewFragSizeGenerator returns a fragment size generator by its name,
+// which is one of constant (the default), exponential, geometric,
+// gamma and lognormal of the mean size,
+// uniform in the range of the parameters, and empirical from the histogram file.
+func NewFragSizeGenerator(name string, mean int, params FragParams, src rand.Source) (FragSizeGenerator, error) {
+	if (name == "geometric" || name == "gamma" || name == "lognormal") && mean <= 0 {
+		return nil, fmt.Errorf("%s fragment sizes require a positive mean, but got %d", name, mean)
+	}
+
+	switch name {
+	case "", "constant":
+		return NewConstantFrag(mean), nil
+	case "exponential":
+		return NewExpFrag(1.0/float64(mean), src), nil
+	case "geometric":
+		return NewGeometricFrag(1.0/float64(mean), src), nil
+	case "gamma":
+		if params.Shape <= 0 {
+			return nil, fmt.Errorf("gamma fragment sizes require a positive shape, but got %f", params.Shape)
+		}
+		return NewGammaFrag(params.Shape, float64(mean)/params.Shape, src), nil
+	case "lognormal":
+		if params.Sigma < 0 {
+			return nil, fmt.Errorf("log-normal fragment sizes require a non-negative sigma, but got %f", params.Sigma)
+		}
+		mu := math.Log(float64(mean)) - params.Sigma*params.Sigma/2
+		return NewLogNormalFrag(mu, params.Sigma, src), nil
+	case "uniform":
+		if params.Min < 0 || params.Max < params.Min {
+			return nil, fmt.Errorf("uniform fragment sizes require 0 <= min <= max, but got %d and %d", params.Min, params.Max)
+		}
+		return NewUniformFrag(params.Min, params.Max, src), nil
+	case "empirical":
+		f, err := os.Open(params.File)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ReadEmpiricalFrag(f, src)
+	}
+	return nil, fmt.Errorf("unknown fragment size generator %s", name)
+}
@@ -0,0 +1,59 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func meanFragSize(frag FragSizeGenerator, n int) float64 {
+	total := 0
+	for i := 0; i < n; i++ {
+		total += frag.Size()
+	}
+	return float64(total) / float64(n)
+}
+
+func TestFragSizeGenerator(t *testing.T) {
+	src := rand.NewSource(1)
+	params := FragParams{Shape: 2, Sigma: 0.5, Min: 10, Max: 30}
+	// gamma and log-normal sizes are truncated to integers.
+	expected := map[string]float64{
+		"constant":  100,
+		"geometric": 100,
+		"gamma":     99.5,
+		"lognormal": 99.5,
+		"uniform":   20,
+	}
+	for name, v := range expected {
+		frag, err := NewFragSizeGenerator(name, 100, params, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := meanFragSize(frag, 100000); math.Abs(m-v) > 0.02*v {
+			t.Errorf("Expect mean size %f of %s, but got %f\n", v, name, m)
+		}
+	}
+
+	if _, err := NewFragSizeGenerator("gamma", 100, FragParams{}, src); err == nil {
+		t.Errorf("Expect error for gamma without shape\n")
+	}
+	if _, err := NewFragSizeGenerator("pareto", 100, params, src); err == nil {
+		t.Errorf("Expect error for unknown generator\n")
+	}
+}
+
+func TestEmpiricalFrag(t *testing.T) {
+	histogram := "# length count\n10 1\n20 3\n\n30\n"
+	frag, err := ReadEmpiricalFrag(strings.NewReader(histogram), rand.NewSource(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := meanFragSize(frag, 100000); math.Abs(m-20) > 0.2 {
+		t.Errorf("Expect mean size 20, but got %f\n", m)
+	}
+	if _, err := ReadEmpiricalFrag(strings.NewReader("ten 1\n"), rand.NewSource(1)); err == nil {
+		t.Errorf("Expect error for malformed histogram\n")
+	}
+}
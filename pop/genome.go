@@ -1,8 +1,152 @@
 package pop
 
+import (
+	"fmt"
+)
+
 type Genome interface {
+	// Seq returns the sequence of the genome,
+	// which operators change in place.
 	Seq() []byte
 	Fitness() float64
 	Length() int
 	Copy() Genome
 }
+
+// FitnessUpdater is a genome whose fitness can be changed by fitness mutators,
+// and which counts the mutations of each class it carries.
+type FitnessUpdater interface {
+	Genome
+	// UpdateFitness adds delta to the fitness, for a mutation of the class.
+	UpdateFitness(delta float64, class MutationClass) error
+	// MutationCounts returns the numbers of mutations of each class.
+	MutationCounts() MutationCounts
+}
+
+// MutableGenome is a genome which tracks the changes of its sequence,
+// so that mutators set its bases through SetBase,
+// instead of changing the sequence from Seq() in place.
+type MutableGenome interface {
+	Genome
+	SetBase(pos int, b byte)
+}
+
+// AnnotatedGenome is a genome carrying metadata,
+// such as its origin or its type, which its copies inherit.
+type AnnotatedGenome interface {
+	Genome
+	// Metadata returns the value of the key, or "" if it is unset.
+	Metadata(key string) string
+	SetMetadata(key, value string)
+}
+
+// UnsupportedGenomeError is returned by an operator
+// given a genome of a type it can't handle.
+type UnsupportedGenomeError struct {
+	Op     string
+	Genome Genome
+}
+
+func (e *UnsupportedGenomeError) Error() string {
+	return fmt.Sprintf("%s does not support genomes of type %T", e.Op, e.Genome)
+}
+
+// IndelGenome is a genome with insertions and deletions,
+// which maps its positions to the coordinates of the ancestral genome.
+type IndelGenome interface {
+	Genome
+	// Coord returns the ancestral coordinate of the position,
+	// which is Inserted or Accessory if the position is inserted.
+	Coord(pos int) int
+	// Bound returns the first position whose ancestral coordinate is not less than coord,
+	// where inserted positions follow their preceding ones.
+	Bound(coord int) int
+	Insert(pos int, seq []byte)
+	// InsertAccessory inserts a foreign sequence as accessory material.
+	InsertAccessory(pos int, seq []byte)
+	Delete(pos, n int)
+	// Replace replaces the positions in the ancestral stretch [start, end)
+	// by those of the donor.
+	Replace(start, end int, donor IndelGenome)
+	// Unchanged reports whether there has been no insertion or deletion,
+	// so that the positions are the ancestral coordinates.
+	Unchanged() bool
+}
+
+// Gap marks the ancestral coordinates deleted from a genome in its alignment.
+const Gap = '-'
+
+// Coordinates of inserted positions.
+const (
+	Inserted  = -1 // inserted by an indel.
+	Accessory = -2 // foreign material inserted by a transfer.
+)
+
+// AlignedSeq returns the sequence of the genome in the ancestral coordinates,
+// in which deleted positions are gaps and inserted ones are left out.
+func AlignedSeq(g Genome, length int) []byte {
+	ig, ok := g.(IndelGenome)
+	if !ok || ig.Unchanged() {
+		return g.Seq()
+	}
+	seq := make([]byte, length)
+	for i := range seq {
+		seq[i] = Gap
+	}
+	for i, b := range g.Seq() {
+		if c := ig.Coord(i); c >= 0 && c < length {
+			seq[c] = b
+		}
+	}
+	return seq
+}
+
+// genomeCoord returns the ancestral coordinate of the position of the genome,
+// which is negative if the position is inserted.
+func genomeCoord(g Genome, pos int) int {
+	if ig, ok := g.(IndelGenome); ok {
+		return ig.Coord(pos)
+	}
+	return pos
+}
+
+// genomePosition returns the position of the ancestral coordinate in the genome,
+// or -1 if it has been deleted.
+func genomePosition(g Genome, coord int) int {
+	ig, ok := g.(IndelGenome)
+	if !ok {
+		return coord
+	}
+	pos := ig.Bound(coord)
+	if pos < g.Length() && ig.Coord(pos) == coord {
+		return pos
+	}
+	return -1
+}
+
+// setBase sets the base at the position of the genome.
+func setBase(g Genome, pos int, b byte) {
+	if bs, ok := g.(MutableGenome); ok {
+		bs.SetBase(pos, b)
+		return
+	}
+	g.Seq()[pos] = b
+}
+
+// copyHomologous replaces the ancestral stretch [start, end) of the recipient
+// by that of the donor.
+func copyHomologous(recipient, donor Genome, start, end int) {
+	r, ok1 := recipient.(IndelGenome)
+	d, ok2 := donor.(IndelGenome)
+	if ok1 && ok2 {
+		r.Replace(start, end, d)
+		return
+	}
+	if _, ok := recipient.(MutableGenome); ok {
+		for i, b := range donor.Seq()[start:end] {
+			setBase(recipient, start+i, b)
+		}
+		return
+	}
+	copy(recipient.Seq()[start:end], donor.Seq()[start:end])
+}
@@ -0,0 +1,108 @@
+package pop
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// countingGenome is a custom genome implementing FitnessUpdater.
+type countingGenome struct {
+	seq     []byte
+	fitness float64
+	counts  MutationCounts
+}
+
+func (g *countingGenome) Seq() []byte      { return g.seq }
+func (g *countingGenome) Fitness() float64 { return g.fitness }
+func (g *countingGenome) Length() int      { return len(g.seq) }
+func (g *countingGenome) Copy() Genome {
+	c := *g
+	c.seq = append([]byte{}, g.seq...)
+	return &c
+}
+func (g *countingGenome) UpdateFitness(delta float64, class MutationClass) error {
+	g.fitness += delta
+	g.counts.Add(class)
+	return nil
+}
+func (g *countingGenome) MutationCounts() MutationCounts { return g.counts }
+
+func TestFitnessUpdater(t *testing.T) {
+	src := rand.NewSource(1)
+	p := New()
+	NewSimplePopGenerator(&countingGenome{seq: []byte("ACGT")}, 1).Operate(p)
+	if err := NewBeneficialMutator(0.1, src).Apply(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewFitnessMutator(0.2, 0, src, FitnessMutateStep).Apply(p); err != nil {
+		t.Fatal(err)
+	}
+	g := p.Genomes[0].(*countingGenome)
+	if g.counts.Beneficial != 2 || g.Fitness() < 0.3-1e-9 || g.Fitness() > 0.3+1e-9 {
+		t.Errorf("Expect 2 beneficial mutations and fitness 0.3, but got %d and %f\n", g.counts.Beneficial, g.Fitness())
+	}
+	if l := CalcMutationLoad(p); l.Beneficial != 2 {
+		t.Errorf("Expect 2 beneficial mutations per genome, but got %f\n", l.Beneficial)
+	}
+
+	// operators return errors for genomes they can't handle.
+	ops := []Applier{
+		NewIndel([]byte("ACGT"), NewConstantFrag(1), 0.5, src),
+		NewPoolInsertionTransfer(NewConstantFrag(1), [][]byte{[]byte("A")}, src),
+	}
+	for _, op := range ops {
+		if _, ok := op.Apply(p).(*UnsupportedGenomeError); !ok {
+			t.Errorf("Expect an UnsupportedGenomeError from %T\n", op)
+		}
+	}
+
+	p = New()
+	NewSimplePopGenerator(NewSequenceGenome([]byte("ACGT"), &AdditiveLandscape{}), 1).Operate(p)
+	if err := NewBeneficialMutator(0.1, src).Apply(p); err != nil {
+		t.Errorf("Expect sequence genomes to take fitness mutations, but got %v\n", err)
+	}
+	if f := p.Genomes[0].Fitness(); f != 0.1 {
+		t.Errorf("Expect fitness 0.1, but got %f\n", f)
+	}
+}
+
+func TestGenomeMetadata(t *testing.T) {
+	var g AnnotatedGenome = &NeutralGenome{Sequence: ByteSequence("ACGT")}
+	if g.Metadata("origin") != "" {
+		t.Errorf("Expect no metadata\n")
+	}
+	g.SetMetadata("origin", "donor")
+	c := g.Copy().(AnnotatedGenome)
+	c.SetMetadata("origin", "recipient")
+	if g.Metadata("origin") != "donor" || c.Metadata("origin") != "recipient" {
+		t.Errorf("Expect copies to inherit metadata without sharing it\n")
+	}
+}
+
+func TestEvolveApply(t *testing.T) {
+	src := rand.NewSource(1)
+	p := New()
+	NewRandomPopGenerator(rand.New(src), 1, 4, []byte("ACGT")).Operate(p)
+	// indels are not supported by a custom genome.
+	q := New()
+	NewSimplePopGenerator(&countingGenome{seq: []byte("ACGT")}, 1).Operate(q)
+
+	eventChan := make(chan *Event)
+	sent := 0
+	go func() {
+		defer close(eventChan)
+		eventChan <- &Event{Ops: NewSimpleMutator([]byte("ACGT"), src), Pop: p}
+		sent++
+		eventChan <- &Event{Ops: NewIndel([]byte("ACGT"), NewConstantFrag(1), 0.5, src), Pop: q}
+		sent++
+	}()
+	err := EvolveApply(eventChan)
+	if _, ok := err.(*UnsupportedGenomeError); !ok {
+		t.Errorf("Expect an UnsupportedGenomeError, but got %v\n", err)
+	}
+	for range eventChan {
+	}
+	if sent != 2 {
+		t.Errorf("Expect 2 events, but got %d\n", sent)
+	}
+}
@@ -0,0 +1,70 @@
+package pop
+
+import (
+	"math/rand"
+
+	"github.com/mingzhi/numgo/random"
+)
+
+// Indel implements insertions and deletions.
+//
+// We randomly choose a genome and a position,
+// and either insert a random sequence before the position,
+// or delete the positions from it,
+// with the length given by the fragment size generator.
+// Genomes must implement IndelGenome.
+type Indel struct {
+	Alphabet  []byte
+	Frag      FragSizeGenerator
+	Insertion float64 // proportion of insertions.
+
+	r *random.Rand
+}
+
+// NewIndel returns a new Indel.
+func NewIndel(alphabet []byte, frag FragSizeGenerator, insertion float64, src rand.Source) *Indel {
+	return &Indel{Alphabet: alphabet, Frag: frag, Insertion: insertion, r: random.New(src)}
+}
+
+// Operate inserts or deletes a stretch of a genome.
+// Deletions are truncated at the end of the genome,
+// and those of the whole genome are ignored.
+func (d *Indel) Operate(p *Pop) {
+	if err := d.Apply(p); err != nil {
+		panic(err)
+	}
+}
+
+// Apply inserts or deletes a stretch of a genome,
+// and returns an error if the genome is not an IndelGenome.
+func (d *Indel) Apply(p *Pop) error {
+	i := d.r.Intn(p.Size())
+	g, ok := p.Genomes[i].(IndelGenome)
+	if !ok {
+		return &UnsupportedGenomeError{Op: "Indel", Genome: p.Genomes[i]}
+	}
+	size := d.Frag.Size()
+	if size < 1 {
+		size = 1
+	}
+	length := g.Length()
+
+	if d.r.Float64() < d.Insertion {
+		pos := d.r.Intn(length + 1)
+		seq := make([]byte, size)
+		for i := range seq {
+			seq[i] = d.Alphabet[d.r.Intn(len(d.Alphabet))]
+		}
+		g.Insert(pos, seq)
+		return nil
+	}
+
+	pos := d.r.Intn(length)
+	if pos+size > length {
+		size = length - pos
+	}
+	if size < length {
+		g.Delete(pos, size)
+	}
+	return nil
+}
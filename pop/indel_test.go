@@ -0,0 +1,93 @@
+package pop
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestNeutralGenomeIndel(t *testing.T) {
+	g := &NeutralGenome{Sequence: ByteSequence("ACGTACGT")}
+	g.Insert(2, []byte("TT"))
+	g.Delete(6, 2)
+	if s := string(g.Seq()); s != "ACTTGTGT" {
+		t.Errorf("Expect sequence ACTTGTGT, but got %s\n", s)
+	}
+	if s := string(AlignedSeq(g, 8)); s != "ACGT--GT" {
+		t.Errorf("Expect alignment ACGT--GT, but got %s\n", s)
+	}
+	for coord, pos := range []int{0, 1, 4, 5, 6, 6, 6, 7} {
+		if v := g.Bound(coord); v != pos {
+			t.Errorf("Expect bound %d of %d, but got %d\n", pos, coord, v)
+		}
+	}
+	if pos := genomePosition(g, 5); pos != -1 {
+		t.Errorf("Expect deleted coordinate, but got position %d\n", pos)
+	}
+
+	// the recipient takes the insertions and deletions of the donor in the stretch.
+	r := &NeutralGenome{Sequence: ByteSequence("CCCCCCCC")}
+	r.Replace(1, 5, g)
+	if s := string(r.Seq()); s != "CCTTGTCCC" {
+		t.Errorf("Expect sequence CCTTGTCCC, but got %s\n", s)
+	}
+	if s := string(AlignedSeq(r, 8)); s != "CCGT-CCC" {
+		t.Errorf("Expect alignment CCGT-CCC, but got %s\n", s)
+	}
+
+	c := r.Copy().(*NeutralGenome)
+	c.Delete(0, 1)
+	if r.Length() != 9 || r.Coord(0) != 0 {
+		t.Errorf("Expect copies not to share coordinates\n")
+	}
+}
+
+func TestIndel(t *testing.T) {
+	src := rand.NewSource(1)
+	r := rand.New(src)
+	alphabet := []byte("ACGT")
+	p := New()
+	NewRandomPopGenerator(r, 10, 200, alphabet).Operate(p)
+	p.EnableARG()
+	p.RecordMutations = true
+	moran := NewMoranSampler(src)
+	mutator := NewSimpleMutator(alphabet, src)
+	transfer := NewSimpleTransfer(NewConstantFrag(30), src)
+	indel := NewIndel(alphabet, NewConstantFrag(5), 0.5, src)
+	for i := 0; i < 2000; i++ {
+		moran.Operate(p)
+		mutator.Operate(p)
+		transfer.Operate(p)
+		indel.Operate(p)
+	}
+
+	varied := false
+	for _, g := range p.Genomes {
+		if g.Length() != p.RefLength() {
+			varied = true
+		}
+		if len(AlignedSeq(g, p.RefLength())) != p.RefLength() {
+			t.Errorf("Expect aligned length %d\n", p.RefLength())
+		}
+		prev := -1
+		for i := 0; i < g.Length(); i++ {
+			c := g.(IndelGenome).Coord(i)
+			if c >= 0 && c <= prev {
+				t.Errorf("Expect increasing coordinates, but got %d after %d\n", c, prev)
+			}
+			if c >= 0 {
+				prev = c
+			}
+		}
+	}
+	if !varied {
+		t.Errorf("Expect genomes of varied lengths\n")
+	}
+	for _, m := range p.Mutations {
+		if m.Pos < 0 || m.Pos >= p.RefLength() {
+			t.Errorf("Expect mutations in the ancestral coordinates, but got %d\n", m.Pos)
+		}
+	}
+	if ks, _ := CalcKs(100, src, p); ks <= 0 {
+		t.Errorf("Expect positive Ks, but got %f\n", ks)
+	}
+}
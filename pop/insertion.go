@@ -0,0 +1,172 @@
+package pop
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math/rand"
+
+	"github.com/mingzhi/numgo/random"
+)
+
+// InsertionTransfer implements non-homologous transfers,
+// which insert foreign fragments into genomes as accessory material.
+//
+// A fragment is taken at a random position of a random genome
+// of the donor population, or of a random sequence of the donor pool
+// if the donor population is nil,
+// and is inserted at a random position of a random recipient genome,
+// which must implement IndelGenome.
+type InsertionTransfer struct {
+	Frag     FragSizeGenerator
+	DonorPop *Pop
+	Pool     [][]byte
+
+	r *random.Rand
+}
+
+// NewInsertionTransfer returns a new InsertionTransfer from a donor population.
+func NewInsertionTransfer(frag FragSizeGenerator, donorPop *Pop, src rand.Source) *InsertionTransfer {
+	return &InsertionTransfer{Frag: frag, DonorPop: donorPop, r: random.New(src)}
+}
+
+// NewPoolInsertionTransfer returns a new InsertionTransfer from a pool of donor sequences.
+func NewPoolInsertionTransfer(frag FragSizeGenerator, pool [][]byte, src rand.Source) *InsertionTransfer {
+	return &InsertionTransfer{Frag: frag, Pool: pool, r: random.New(src)}
+}
+
+// Operate inserts a foreign fragment into a genome.
+func (t *InsertionTransfer) Operate(p *Pop) {
+	if err := t.Apply(p); err != nil {
+		panic(err)
+	}
+}
+
+// Apply inserts a foreign fragment into a genome,
+// and returns an error if the genome is not an IndelGenome.
+func (t *InsertionTransfer) Apply(p *Pop) error {
+	fragment := t.fragment()
+	if len(fragment) == 0 {
+		return nil
+	}
+	i := t.r.Intn(p.Size())
+	g, ok := p.Genomes[i].(IndelGenome)
+	if !ok {
+		return &UnsupportedGenomeError{Op: "InsertionTransfer", Genome: p.Genomes[i]}
+	}
+	g.InsertAccessory(t.r.Intn(g.Length()+1), fragment)
+	return nil
+}
+
+// fragment returns a copy of a random fragment of a donor sequence,
+// which wraps around the end of a circled donor genome,
+// and is truncated at the end otherwise.
+func (t *InsertionTransfer) fragment() []byte {
+	var seq []byte
+	circled := false
+	if t.DonorPop != nil {
+		if t.DonorPop.Size() == 0 {
+			return nil
+		}
+		seq = t.DonorPop.Genomes[t.r.Intn(t.DonorPop.Size())].Seq()
+		circled = t.DonorPop.Circled
+	} else {
+		if len(t.Pool) == 0 {
+			return nil
+		}
+		seq = t.Pool[t.r.Intn(len(t.Pool))]
+	}
+	if len(seq) == 0 {
+		return nil
+	}
+
+	size := t.Frag.Size()
+	if size > len(seq) {
+		size = len(seq)
+	}
+	start := t.r.Intn(len(seq))
+	fragment := []byte{}
+	if start+size <= len(seq) {
+		fragment = append(fragment, seq[start:start+size]...)
+	} else {
+		fragment = append(fragment, seq[start:]...)
+		if circled {
+			fragment = append(fragment, seq[:start+size-len(seq)]...)
+		}
+	}
+	return fragment
+}
+
+// ReadDonorPool reads a pool of donor sequences in FASTA format.
+func ReadDonorPool(r io.Reader) ([][]byte, error) {
+	pool := [][]byte{}
+	var seq []byte
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '>' {
+			if seq != nil {
+				pool = append(pool, seq)
+			}
+			seq = []byte{}
+			continue
+		}
+		seq = append(seq, bytes.ToUpper(line)...)
+	}
+	if seq != nil {
+		pool = append(pool, seq)
+	}
+	return pool, scanner.Err()
+}
+
+// IsAccessory returns whether the position of the genome
+// is accessory material inserted by a transfer.
+func IsAccessory(g Genome, pos int) bool {
+	return genomeCoord(g, pos) == Accessory
+}
+
+// AccessorySeq returns the accessory material of the genome,
+// in the order of positions.
+func AccessorySeq(g Genome) []byte {
+	seq := []byte{}
+	for i, b := range g.Seq() {
+		if IsAccessory(g, i) {
+			seq = append(seq, b)
+		}
+	}
+	return seq
+}
+
+// AccessoryStats summarizes the accessory material of a population.
+type AccessoryStats struct {
+	MeanSize     float64 // mean number of accessory sites per genome.
+	MeanFraction float64 // mean fraction of accessory sites in a genome.
+	Carriers     float64 // fraction of genomes carrying accessory material.
+}
+
+// CalcAccessory calculates the statistics of the accessory material,
+// while the core sites are given by the aligned sequences (see AlignedSeq).
+func CalcAccessory(p *Pop) AccessoryStats {
+	var s AccessoryStats
+	if p.Size() == 0 {
+		return s
+	}
+	for _, g := range p.Genomes {
+		n := len(AccessorySeq(g))
+		s.MeanSize += float64(n)
+		if g.Length() > 0 {
+			s.MeanFraction += float64(n) / float64(g.Length())
+		}
+		if n > 0 {
+			s.Carriers++
+		}
+	}
+	s.MeanSize /= float64(p.Size())
+	s.MeanFraction /= float64(p.Size())
+	s.Carriers /= float64(p.Size())
+	return s
+}
@@ -0,0 +1,64 @@
+package pop
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestReadDonorPool(t *testing.T) {
+	fasta := ">a plasmid\nacgt\nAC\n\n>b\nTTTT\n"
+	pool, err := ReadDonorPool(strings.NewReader(fasta))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pool) != 2 || string(pool[0]) != "ACGTAC" || string(pool[1]) != "TTTT" {
+		t.Errorf("Expect sequences ACGTAC and TTTT, but got %q\n", pool)
+	}
+}
+
+func TestInsertionTransfer(t *testing.T) {
+	src := rand.NewSource(1)
+	p := New()
+	NewRandomPopGenerator(rand.New(src), 10, 100, []byte("ACGT")).Operate(p)
+	core := [][]byte{}
+	for _, g := range p.Genomes {
+		core = append(core, append([]byte{}, g.Seq()...))
+	}
+
+	pool := [][]byte{bytes.Repeat([]byte("N"), 50)}
+	insertion := NewPoolInsertionTransfer(NewConstantFrag(10), pool, src)
+	for i := 0; i < 20; i++ {
+		insertion.Operate(p)
+	}
+
+	// fragments are truncated at the ends of the pool sequences.
+	a := CalcAccessory(p)
+	if a.MeanSize <= 0 || a.MeanSize > 20 {
+		t.Errorf("Expect up to 20 accessory sites per genome, but got %f\n", a.MeanSize)
+	}
+	if a.Carriers <= 0 || a.Carriers > 1 {
+		t.Errorf("Expect a fraction of carriers, but got %f\n", a.Carriers)
+	}
+	for i, g := range p.Genomes {
+		if s := AlignedSeq(g, p.RefLength()); !bytes.Equal(s, core[i]) {
+			t.Errorf("Expect core genome %s, but got %s\n", core[i], s)
+		}
+		for _, b := range AccessorySeq(g) {
+			if b != 'N' {
+				t.Errorf("Expect accessory material from the pool, but got %c\n", b)
+			}
+		}
+	}
+
+	// accessory material inside a fragment moves with homologous transfers.
+	transfer := NewSimpleTransfer(NewConstantFrag(100), src)
+	p.Circled = true
+	for i := 0; i < 100; i++ {
+		transfer.Operate(p)
+	}
+	if v := CalcAccessory(p).MeanSize; v == a.MeanSize {
+		t.Errorf("Expect homologous transfers to change accessory material\n")
+	}
+}
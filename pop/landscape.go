@@ -0,0 +1,305 @@
+package pop
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Landscape computes the fitness of a sequence.
+type Landscape interface {
+	Fitness(seq []byte) float64
+}
+
+// SequenceGenome is a genome whose fitness is computed from its sequence
+// through a fitness landscape,
+// in the ancestral coordinates of the sites in case of indels,
+// plus the effects of fitness mutations.
+//
+// The fitness is recomputed after changes of the sequence
+// through SetBase, insertions, deletions and replacements.
+// Changes through Seq() require a call of Touch.
+type SequenceGenome struct {
+	NeutralGenome
+	Landscape Landscape
+
+	refLength int     // length of the ancestral genome.
+	cached    float64 // fitness given by the landscape.
+	dirty     bool
+}
+
+// NewSequenceGenome returns a new SequenceGenome.
+func NewSequenceGenome(seq []byte, landscape Landscape) *SequenceGenome {
+	return &SequenceGenome{
+		NeutralGenome: NeutralGenome{Sequence: seq},
+		Landscape:     landscape,
+		refLength:     len(seq),
+		dirty:         true,
+	}
+}
+
+// SetBase sets the base at the position.
+func (g *SequenceGenome) SetBase(pos int, b byte) {
+	g.dirty = true
+	g.Sequence[pos] = b
+}
+
+// Touch marks the fitness to be recomputed.
+func (g *SequenceGenome) Touch() {
+	g.dirty = true
+}
+
+// Fitness returns the fitness given by the landscape,
+// plus the effects of fitness mutations.
+func (g *SequenceGenome) Fitness() float64 {
+	if g.dirty {
+		g.cached = g.Landscape.Fitness(AlignedSeq(&g.NeutralGenome, g.refLength))
+		g.dirty = false
+	}
+	return g.cached + g.NeutralGenome.Fitness()
+}
+
+// MultiplicativeFitness returns exp(f) of the fitness f given by the landscape,
+// multiplied by the effects of fitness mutations.
+func (g *SequenceGenome) MultiplicativeFitness() float64 {
+	g.Fitness()
+	return math.Exp(g.cached) * g.NeutralGenome.MultiplicativeFitness()
+}
+
+func (g *SequenceGenome) Copy() Genome {
+	g1 := SequenceGenome{Landscape: g.Landscape, refLength: g.refLength, cached: g.cached, dirty: g.dirty}
+	g1.NeutralGenome = *g.NeutralGenome.Copy().(*NeutralGenome)
+	return &g1
+}
+
+func (g *SequenceGenome) Insert(pos int, seq []byte) {
+	g.dirty = true
+	g.NeutralGenome.Insert(pos, seq)
+}
+
+func (g *SequenceGenome) InsertAccessory(pos int, seq []byte) {
+	g.dirty = true
+	g.NeutralGenome.InsertAccessory(pos, seq)
+}
+
+func (g *SequenceGenome) Delete(pos, n int) {
+	g.dirty = true
+	g.NeutralGenome.Delete(pos, n)
+}
+
+func (g *SequenceGenome) Replace(start, end int, donor IndelGenome) {
+	g.dirty = true
+	g.NeutralGenome.Replace(start, end, donor)
+}
+
+// AdditiveLandscape sums the effects of the bases at the sites,
+// where Effects[i][k] is the effect of the k-th base of the alphabet at the site i.
+// Bases not in the alphabet have no effect.
+type AdditiveLandscape struct {
+	Alphabet []byte
+	Effects  [][]float64
+}
+
+// NewAdditiveLandscape returns an AdditiveLandscape of the length,
+// with effects drawn from a normal distribution of the standard deviation.
+func NewAdditiveLandscape(alphabet []byte, length int, sd float64, src rand.Source) *AdditiveLandscape {
+	r := rand.New(src)
+	a := AdditiveLandscape{Alphabet: alphabet, Effects: make([][]float64, length)}
+	for i := range a.Effects {
+		a.Effects[i] = make([]float64, len(alphabet))
+		for k := range a.Effects[i] {
+			a.Effects[i][k] = r.NormFloat64() * sd
+		}
+	}
+	return &a
+}
+
+// Fitness returns the sum of the effects.
+func (a *AdditiveLandscape) Fitness(seq []byte) float64 {
+	f := 0.0
+	for i, b := range seq {
+		if i >= len(a.Effects) {
+			break
+		}
+		if k := bytes.IndexByte(a.Alphabet, b); k >= 0 {
+			f += a.Effects[i][k]
+		}
+	}
+	return f
+}
+
+// Epistasis is the interaction between the sites I and J,
+// where Effects[k][l] is the effect of the k-th base at I
+// and the l-th base at J.
+type Epistasis struct {
+	I, J    int
+	Effects [][]float64
+}
+
+// EpistaticLandscape adds pairwise interactions to additive effects.
+type EpistaticLandscape struct {
+	AdditiveLandscape
+	Pairs []Epistasis
+}
+
+// NewEpistaticLandscape returns an EpistaticLandscape of the length,
+// with the number of random pairs of sites,
+// and additive and pairwise effects drawn from normal distributions
+// of the standard deviations.
+func NewEpistaticLandscape(alphabet []byte, length, numPairs int, sd, pairSD float64, src rand.Source) *EpistaticLandscape {
+	e := EpistaticLandscape{AdditiveLandscape: *NewAdditiveLandscape(alphabet, length, sd, src)}
+	r := rand.New(src)
+	for n := 0; n < numPairs && length > 1; n++ {
+		i := r.Intn(length)
+		j := r.Intn(length - 1)
+		if j >= i {
+			j++
+		}
+		pair := Epistasis{I: i, J: j, Effects: make([][]float64, len(alphabet))}
+		for k := range pair.Effects {
+			pair.Effects[k] = make([]float64, len(alphabet))
+			for l := range pair.Effects[k] {
+				pair.Effects[k][l] = r.NormFloat64() * pairSD
+			}
+		}
+		e.Pairs = append(e.Pairs, pair)
+	}
+	return &e
+}
+
+// Fitness returns the sum of the additive and pairwise effects.
+func (e *EpistaticLandscape) Fitness(seq []byte) float64 {
+	f := e.AdditiveLandscape.Fitness(seq)
+	for _, p := range e.Pairs {
+		if p.I >= len(seq) || p.J >= len(seq) {
+			continue
+		}
+		k := bytes.IndexByte(e.Alphabet, seq[p.I])
+		l := bytes.IndexByte(e.Alphabet, seq[p.J])
+		if k >= 0 && l >= 0 {
+			f += p.Effects[k][l]
+		}
+	}
+	return f
+}
+
+// NKLandscape is the NK model of Kauffman,
+// in which the contribution of each of the N sites depends on its base
+// and the bases of K other sites.
+// The fitness is the mean of the contributions, multiplied by Scale.
+type NKLandscape struct {
+	Alphabet  []byte
+	K         int
+	Scale     float64
+	Neighbors [][]int     // the site itself followed by its K neighbors.
+	Tables    [][]float64 // contributions of the combinations of bases.
+}
+
+// NewNKLandscape returns an NKLandscape of the length N,
+// whose neighbors are the next K sites (circularly) if adjacent is true,
+// or K random sites otherwise,
+// and whose contributions are drawn uniformly from [0, 1).
+func NewNKLandscape(alphabet []byte, n, k int, adjacent bool, scale float64, src rand.Source) (*NKLandscape, error) {
+	if k < 0 || k >= n {
+		return nil, fmt.Errorf("NK landscape requires 0 <= K < N, but got K = %d and N = %d", k, n)
+	}
+	size := 1
+	for i := 0; i <= k; i++ {
+		size *= len(alphabet)
+	}
+
+	r := rand.New(src)
+	m := NKLandscape{Alphabet: alphabet, K: k, Scale: scale}
+	for i := 0; i < n; i++ {
+		neighbors := []int{i}
+		if adjacent {
+			for j := 1; j <= k; j++ {
+				neighbors = append(neighbors, (i+j)%n)
+			}
+		} else {
+			for _, j := range r.Perm(n - 1)[:k] {
+				if j >= i {
+					j++
+				}
+				neighbors = append(neighbors, j)
+			}
+		}
+		table := make([]float64, size)
+		for j := range table {
+			table[j] = r.Float64()
+		}
+		m.Neighbors = append(m.Neighbors, neighbors)
+		m.Tables = append(m.Tables, table)
+	}
+	return &m, nil
+}
+
+// Fitness returns the mean of the contributions multiplied by Scale.
+// Sites with bases not in the alphabet contribute nothing.
+func (m *NKLandscape) Fitness(seq []byte) float64 {
+	if len(m.Neighbors) == 0 {
+		return 0
+	}
+	f := 0.0
+	for i, neighbors := range m.Neighbors {
+		index := 0
+		valid := true
+		for _, j := range neighbors {
+			k := -1
+			if j < len(seq) {
+				k = bytes.IndexByte(m.Alphabet, seq[j])
+			}
+			if k < 0 {
+				valid = false
+				break
+			}
+			index = index*len(m.Alphabet) + k
+		}
+		if valid {
+			f += m.Tables[i][index]
+		}
+	}
+	return m.Scale * f / float64(len(m.Neighbors))
+}
+
+// LandscapeParams stores the parameters of fitness landscapes.
+type LandscapeParams struct {
+	Name string
+	// SD is the standard deviation of additive effects.
+	SD float64
+	// number of interacting Pairs of sites,
+	// and the standard deviation of their effects.
+	Pairs  int
+	PairSD float64
+	// K neighbors of each site in NK landscapes,
+	// which are the next sites if Adjacent, or random ones otherwise,
+	// and the Scale of the fitness.
+	K        int
+	Adjacent bool
+	Scale    float64
+}
+
+// NewLandscape returns a fitness landscape of genomes of the length,
+// which is one of additive, epistatic and nk,
+// or nil without a name.
+func NewLandscape(alphabet []byte, length int, params LandscapeParams, src rand.Source) (Landscape, error) {
+	switch params.Name {
+	case "":
+		return nil, nil
+	case "additive":
+		return NewAdditiveLandscape(alphabet, length, params.SD, src), nil
+	case "epistatic":
+		if params.Pairs < 0 {
+			return nil, fmt.Errorf("epistatic landscape requires a non-negative number of pairs, but got %d", params.Pairs)
+		}
+		return NewEpistaticLandscape(alphabet, length, params.Pairs, params.SD, params.PairSD, src), nil
+	case "nk":
+		scale := params.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		return NewNKLandscape(alphabet, length, params.K, params.Adjacent, scale, src)
+	}
+	return nil, fmt.Errorf("unknown fitness landscape %s", params.Name)
+}
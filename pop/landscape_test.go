@@ -0,0 +1,196 @@
+package pop
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLandscapes(t *testing.T) {
+	alphabet := []byte("ACGT")
+	additive := &AdditiveLandscape{Alphabet: alphabet, Effects: [][]float64{{1, 0, 0, 0}, {0, 2, 0, 0}}}
+	if f := additive.Fitness([]byte("AC")); f != 3 {
+		t.Errorf("Expect additive fitness 3, but got %f\n", f)
+	}
+	if f := additive.Fitness([]byte("A-")); f != 1 {
+		t.Errorf("Expect gaps to have no effect, but got fitness %f\n", f)
+	}
+
+	epistatic := &EpistaticLandscape{AdditiveLandscape: *additive}
+	epistatic.Pairs = []Epistasis{{I: 0, J: 1, Effects: make([][]float64, 4)}}
+	for k := range epistatic.Pairs[0].Effects {
+		epistatic.Pairs[0].Effects[k] = make([]float64, 4)
+	}
+	epistatic.Pairs[0].Effects[0][1] = -3
+	if f := epistatic.Fitness([]byte("AC")); f != 0 {
+		t.Errorf("Expect epistatic fitness 0, but got %f\n", f)
+	}
+	if f := epistatic.Fitness([]byte("AG")); f != 1 {
+		t.Errorf("Expect epistatic fitness 1, but got %f\n", f)
+	}
+
+	src := rand.NewSource(1)
+	if _, err := NewNKLandscape(alphabet, 10, 10, true, 1, src); err == nil {
+		t.Errorf("Expect an error for K >= N\n")
+	}
+	nk, err := NewNKLandscape(alphabet, 20, 3, false, 2, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq := make([]byte, 20)
+	r := rand.New(src)
+	for i := range seq {
+		seq[i] = alphabet[r.Intn(4)]
+	}
+	f := nk.Fitness(seq)
+	if f <= 0 || f >= 2 {
+		t.Errorf("Expect NK fitness in (0, 2), but got %f\n", f)
+	}
+	// changing a site changes the contributions of the sites depending on it.
+	seq[0] = alphabet[(bytes.IndexByte(alphabet, seq[0])+1)%4]
+	if f1 := nk.Fitness(seq); f1 == f {
+		t.Errorf("Expect NK fitness to change with the sequence\n")
+	}
+}
+
+func TestSequenceGenome(t *testing.T) {
+	alphabet := []byte("ACGT")
+	effects := make([][]float64, 10)
+	for i := range effects {
+		effects[i] = []float64{0, 1, 0, 0}
+	}
+	landscape := &AdditiveLandscape{Alphabet: alphabet, Effects: effects}
+	g := NewSequenceGenome([]byte("AAAAAAAAAA"), landscape)
+	if g.Fitness() != 0 {
+		t.Errorf("Expect fitness 0, but got %f\n", g.Fitness())
+	}
+	g.SetBase(3, 'C')
+	if g.Fitness() != 1 {
+		t.Errorf("Expect fitness 1 after a change of the sequence, but got %f\n", g.Fitness())
+	}
+	c := g.Copy().(*SequenceGenome)
+	c.SetBase(4, 'C')
+	if g.Fitness() != 1 || c.Fitness() != 2 {
+		t.Errorf("Expect copies not to share sequences\n")
+	}
+	g.Delete(3, 1)
+	if g.Fitness() != 0 {
+		t.Errorf("Expect fitness 0 after a deletion, but got %f\n", g.Fitness())
+	}
+
+	// transfers and mutations change fitness, and selection increases it.
+	src := rand.NewSource(1)
+	p := New()
+	NewSimplePopGenerator(NewSequenceGenome([]byte("AAAAAAAAAA"), landscape), 50).Operate(p)
+	moran := NewMoranSampler(src)
+	mutator := NewSimpleMutator(alphabet, src)
+	transfer := NewSimpleTransfer(NewConstantFrag(3), src)
+	for i := 0; i < 5000; i++ {
+		moran.Operate(p)
+		mutator.Operate(p)
+		transfer.Operate(p)
+	}
+	mean := 0.0
+	for _, g := range p.Genomes {
+		mean += g.Fitness()
+	}
+	mean /= float64(p.Size())
+	// neutral expectation is 2.5 beneficial sites.
+	if mean <= 2.5 || math.IsNaN(mean) {
+		t.Errorf("Expect selection to increase mean fitness above 2.5, but got %f\n", mean)
+	}
+}
+
+// countingLandscape counts the computations of fitness.
+type countingLandscape struct {
+	AdditiveLandscape
+	calls int
+}
+
+func (c *countingLandscape) Fitness(seq []byte) float64 {
+	c.calls++
+	return c.AdditiveLandscape.Fitness(seq)
+}
+
+func TestSequenceGenomeCoords(t *testing.T) {
+	effects := make([][]float64, 5)
+	for i := range effects {
+		effects[i] = []float64{0, float64(i + 1)}
+	}
+	landscape := &countingLandscape{AdditiveLandscape: AdditiveLandscape{Alphabet: []byte("AC"), Effects: effects}}
+	g := NewSequenceGenome([]byte("ACACA"), landscape)
+	if f := g.Fitness(); f != 6 {
+		t.Errorf("Expect fitness 6, but got %f\n", f)
+	}
+	// reads of the sequence don't recompute the fitness.
+	for i := 0; i < 10; i++ {
+		g.Seq()
+		g.Fitness()
+	}
+	if landscape.calls != 1 {
+		t.Errorf("Expect the fitness computed once, but got %d times\n", landscape.calls)
+	}
+
+	// the sites keep their ancestral coordinates through indels.
+	g.Insert(0, []byte("CC"))
+	if f := g.Fitness(); f != 6 {
+		t.Errorf("Expect fitness 6 after an insertion, but got %f\n", f)
+	}
+	g.Delete(2, 2)
+	if f := g.Fitness(); f != 4 {
+		t.Errorf("Expect fitness 4 after a deletion of the site 1, but got %f\n", f)
+	}
+
+	// fitness mutations add to the fitness given by the landscape.
+	if err := g.UpdateFitness(0.5, BeneficialMutation); err != nil {
+		t.Fatal(err)
+	}
+	c := g.Copy()
+	if c.Fitness() != 4.5 || c.(FitnessUpdater).MutationCounts().Beneficial != 1 {
+		t.Errorf("Expect fitness 4.5 with a beneficial mutation, but got %f\n", c.Fitness())
+	}
+}
+
+func TestSequenceGenomeReplace(t *testing.T) {
+	effects := make([][]float64, 5)
+	for i := range effects {
+		effects[i] = []float64{0, float64(i + 1)}
+	}
+	landscape := &AdditiveLandscape{Alphabet: []byte("AC"), Effects: effects}
+	g := NewSequenceGenome([]byte("AAAAA"), landscape)
+	d := NewSequenceGenome([]byte("CCCCC"), landscape)
+
+	// transfers between genomes without indels don't create coordinates.
+	copyHomologous(g, d, 1, 3)
+	if string(g.Seq()) != "ACCAA" || !g.Unchanged() {
+		t.Errorf("Expect ACCAA without coordinates, but got %s\n", g.Seq())
+	}
+	if f := g.Fitness(); f != 5 {
+		t.Errorf("Expect fitness 5 after the transfer, but got %f\n", f)
+	}
+}
+
+func TestLandscapePopGenerator(t *testing.T) {
+	src := rand.NewSource(1)
+	if _, err := NewLandscape([]byte("ACGT"), 10, LandscapeParams{Name: "nk", K: 10}, src); err == nil {
+		t.Errorf("Expect an error for K >= N\n")
+	}
+	landscape, err := NewLandscape([]byte("ACGT"), 10, LandscapeParams{Name: "additive", SD: 1}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewRandomPopGenerator(rand.New(src), 5, 10, []byte("ACGT"))
+	g.Landscape = landscape
+	p := New()
+	g.Operate(p)
+	for _, genome := range p.Genomes {
+		sg, ok := genome.(*SequenceGenome)
+		if !ok {
+			t.Fatalf("Expect SequenceGenomes, but got %T\n", genome)
+		}
+		if f := landscape.Fitness(p.Ancestor); sg.Fitness() != f {
+			t.Errorf("Expect the fitness %f of the ancestor, but got %f\n", f, sg.Fitness())
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+)
+
+// LD stores the decay of linkage disequilibrium with distance.
+// The i-th bin contains pairs of segregating sites,
+// whose distance d satisfies i*BinSize <= d < (i+1)*BinSize.
+type LD struct {
+	BinSize int
+	R2      []float64 // mean of r^2.
+	DPrime  []float64 // mean of |D'|.
+	N       []int     // number of site pairs.
+}
+
+// CalcLD calculates r^2 and D' between pairs of segregating sites
+// in a random sample from the population, up to the distance of maxl.
+//
+// Each segregating site is reduced to two alleles:
+// the major allele, and all the others.
+// For a circled genome, the distance between two sites is
+// the shorter one of the two arcs.
+func CalcLD(sampleSize, maxl, binSize int, src rand.Source, p *Pop) LD {
+	sample := SampleGenomes(sampleSize, src, p)
+	seqs := [][]byte{}
+	for _, g := range sample {
+		seqs = append(seqs, AlignedSeq(g, p.RefLength()))
+	}
+	return calcLD(seqs, maxl, binSize, p.Circled)
+}
+
+func calcLD(seqs [][]byte, maxl, binSize int, circular bool) LD {
+	if binSize < 1 {
+		binSize = 1
+	}
+	var length int
+	if len(seqs) > 0 {
+		length = len(seqs[0])
+	}
+
+	if circular {
+		if maxl <= 0 || maxl > length/2 {
+			maxl = length / 2
+		}
+	} else {
+		if maxl <= 0 || maxl > length-1 {
+			maxl = length - 1
+		}
+	}
+
+	numBins := maxl/binSize + 1
+	ld := LD{
+		BinSize: binSize,
+		R2:      make([]float64, numBins),
+		DPrime:  make([]float64, numBins),
+		N:       make([]int, numBins),
+	}
+	if maxl < 1 {
+		return ld
+	}
+
+	alleles := minorIndicators(seqs)
+	for s := 0; s < length; s++ {
+		if alleles[s] == nil {
+			continue
+		}
+		for d := 1; d <= maxl; d++ {
+			t := s + d
+			if t >= length {
+				if !circular {
+					break
+				}
+				t -= length
+			}
+			// both arcs have the same length,
+			// so we count the pair only once.
+			if circular && 2*d == length && t < s {
+				continue
+			}
+			if alleles[t] == nil {
+				continue
+			}
+			r2, dp := linkage(alleles[s], alleles[t])
+			b := d / binSize
+			ld.R2[b] += r2
+			ld.DPrime[b] += dp
+			ld.N[b]++
+		}
+	}
+
+	for b := 0; b < numBins; b++ {
+		if ld.N[b] > 0 {
+			ld.R2[b] /= float64(ld.N[b])
+			ld.DPrime[b] /= float64(ld.N[b])
+		}
+	}
+	return ld
+}
+
+// minorIndicators returns, for each segregating site,
+// a vector in which 1 indicates a non-major allele of the sequence.
+// Monomorphic sites are nil.
+func minorIndicators(seqs [][]byte) [][]float64 {
+	if len(seqs) == 0 {
+		return nil
+	}
+	length := len(seqs[0])
+	indicators := make([][]float64, length)
+	counts := make(map[byte]int)
+	for pos := 0; pos < length; pos++ {
+		for k := range counts {
+			delete(counts, k)
+		}
+		var major byte
+		majorCount := 0
+		for _, s := range seqs {
+			counts[s[pos]]++
+			if counts[s[pos]] > majorCount {
+				major = s[pos]
+				majorCount = counts[major]
+			}
+		}
+		if majorCount == len(seqs) {
+			continue
+		}
+		x := make([]float64, len(seqs))
+		for i, s := range seqs {
+			if s[pos] != major {
+				x[i] = 1
+			}
+		}
+		indicators[pos] = x
+	}
+	return indicators
+}
+
+// linkage returns r^2 and |D'| between two bi-allelic sites.
+func linkage(a, b []float64) (r2, dprime float64) {
+	var pa, pb, pab float64
+	for i := range a {
+		pa += a[i]
+		pb += b[i]
+		pab += a[i] * b[i]
+	}
+	n := float64(len(a))
+	pa /= n
+	pb /= n
+	pab /= n
+
+	d := pab - pa*pb
+	r2 = d * d / (pa * (1 - pa) * pb * (1 - pb))
+
+	var dmax float64
+	if d > 0 {
+		dmax = math.Min(pa*(1-pb), (1-pa)*pb)
+	} else {
+		dmax = math.Min(pa*pb, (1-pa)*(1-pb))
+	}
+	if dmax > 0 {
+		dprime = math.Abs(d) / dmax
+	}
+	return
+}
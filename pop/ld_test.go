@@ -0,0 +1,45 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCalcLD(t *testing.T) {
+	tolerance := 1e-8
+	p := New()
+	// site 0 and site 2 are in perfect linkage,
+	// and site 1 is independent of both.
+	for _, s := range []string{"AAA", "ATA", "TAT", "TTT"} {
+		p.Genomes = append(p.Genomes, &NeutralGenome{Sequence: ByteSequence(s)})
+	}
+
+	ld := CalcLD(10, 0, 1, rand.NewSource(1), p)
+	expR2 := []float64{0, 0, 1}
+	expN := []int{0, 2, 1}
+	for i := range expR2 {
+		if ld.N[i] != expN[i] {
+			t.Errorf("Expect %d pairs at %d, but got %d\n", expN[i], i, ld.N[i])
+		}
+		if math.Abs(ld.R2[i]-expR2[i]) > tolerance {
+			t.Errorf("Expect r2 %f at %d, but got %f\n", expR2[i], i, ld.R2[i])
+		}
+	}
+	if math.Abs(ld.DPrime[2]-1) > tolerance {
+		t.Errorf("Expect D' %f, but got %f\n", 1.0, ld.DPrime[2])
+	}
+
+	// in a circled genome, site 2 is next to site 0.
+	p.Circled = true
+	ld = CalcLD(10, 0, 1, rand.NewSource(1), p)
+	if len(ld.R2) != 2 {
+		t.Fatalf("Expect %d bins, but got %d\n", 2, len(ld.R2))
+	}
+	if ld.N[1] != 3 {
+		t.Errorf("Expect %d pairs, but got %d\n", 3, ld.N[1])
+	}
+	if math.Abs(ld.R2[1]-1.0/3.0) > tolerance {
+		t.Errorf("Expect r2 %f, but got %f\n", 1.0/3.0, ld.R2[1])
+	}
+}
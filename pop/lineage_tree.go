@@ -0,0 +1,130 @@
+package pop
+
+// LineageTree indexes the genealogy of a set of lineages
+// for answering most recent common ancestor (MRCA) queries
+// in logarithmic time, using binary lifting.
+//
+// Lineages without parents are treated as the daughters
+// of a virtual root, which splits at their birth time.
+type LineageTree struct {
+	index map[*Lineage]int
+	nodes []*Lineage
+	depth []int
+	up    [][]int // up[k][v] is the 2^k-th ancestor of v, or -1.
+}
+
+// NewLineageTree builds a LineageTree over the lineages and their ancestors.
+func NewLineageTree(lineages Lineages) *LineageTree {
+	t := &LineageTree{index: make(map[*Lineage]int)}
+
+	parents := []int{}
+	path := Lineages{}
+	for _, l := range lineages {
+		// collect the ancestors which have not been indexed yet,
+		// and index them from the oldest one.
+		path = path[:0]
+		for a := l; a != nil; a = a.Parent {
+			if _, found := t.index[a]; found {
+				break
+			}
+			path = append(path, a)
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			a := path[i]
+			parent, depth := -1, 0
+			if a.Parent != nil {
+				parent = t.index[a.Parent]
+				depth = t.depth[parent] + 1
+			}
+			t.index[a] = len(t.nodes)
+			t.nodes = append(t.nodes, a)
+			t.depth = append(t.depth, depth)
+			parents = append(parents, parent)
+		}
+	}
+
+	t.up = append(t.up, parents)
+	for k := 1; 1<<uint(k) < len(t.nodes); k++ {
+		prev := t.up[k-1]
+		next := make([]int, len(t.nodes))
+		for v := range next {
+			if prev[v] < 0 {
+				next[v] = -1
+			} else {
+				next[v] = prev[prev[v]]
+			}
+		}
+		t.up = append(t.up, next)
+	}
+
+	return t
+}
+
+// ancestor returns the ancestor of v which is n generations up.
+func (t *LineageTree) ancestor(v, n int) int {
+	for k := 0; n > 0 && v >= 0; k++ {
+		if n&1 == 1 {
+			v = t.up[k][v]
+		}
+		n >>= 1
+	}
+	return v
+}
+
+// split returns the time when the lineages a and b split,
+// which is the birth time of the daughters of their MRCA.
+// If a is an ancestor of b (or vice versa), it returns the birth time of a.
+func (t *LineageTree) split(a, b *Lineage) int {
+	u, v := t.index[a], t.index[b]
+	if t.depth[u] < t.depth[v] {
+		u, v = v, u
+	}
+	u = t.ancestor(u, t.depth[u]-t.depth[v])
+	if u == v {
+		return t.nodes[u].BirthTime
+	}
+	for k := len(t.up) - 1; k >= 0; k-- {
+		if t.up[k][u] != t.up[k][v] {
+			u, v = t.up[k][u], t.up[k][v]
+		}
+	}
+	// u and v are now daughters of the MRCA,
+	// or of the virtual root.
+	return t.nodes[u].BirthTime
+}
+
+// CoalTime returns the time of the most recent coalescence
+// among the lineages, which must be indexed in the tree.
+func (t *LineageTree) CoalTime(lineages ...*Lineage) int {
+	if len(lineages) == 0 {
+		return 0
+	} else if len(lineages) == 1 {
+		return lineages[0].BirthTime
+	}
+	time := t.split(lineages[0], lineages[1])
+	for i := 0; i < len(lineages); i++ {
+		for j := i + 1; j < len(lineages); j++ {
+			if s := t.split(lineages[i], lineages[j]); s > time {
+				time = s
+			}
+		}
+	}
+	return time
+}
+
+// MRCATime returns the time when all the lineages coalesce,
+// that is, the split time of their MRCA.
+func (t *LineageTree) MRCATime(lineages ...*Lineage) int {
+	if len(lineages) == 0 {
+		return 0
+	} else if len(lineages) == 1 {
+		return lineages[0].BirthTime
+	}
+	time := t.split(lineages[0], lineages[1])
+	for i := 2; i < len(lineages); i++ {
+		if s := t.split(lineages[0], lineages[i]); s < time {
+			time = s
+		}
+	}
+	return time
+}
@@ -0,0 +1,124 @@
+package pop
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/mingzhi/numgo/random"
+)
+
+// Migration replaces a random genome of a population
+// by a copy of a random genome of the source population,
+// so that the sizes of the populations are kept.
+// The lineage of the source genome splits into those of the two copies,
+// at the common generation of the two populations, whose clocks are synchronized.
+type Migration struct {
+	Source *Pop
+
+	r *random.Rand
+}
+
+// NewMigration returns a new Migration from the source population.
+func NewMigration(source *Pop, src rand.Source) *Migration {
+	return &Migration{Source: source, r: random.New(src)}
+}
+
+// Operate moves a migrant from the source population into the population.
+func (m *Migration) Operate(p *Pop) {
+	if m.Source.Size() == 0 || p.Size() == 0 {
+		return
+	}
+	if len(p.Lineages) < p.Size() {
+		p.NewLineages()
+	}
+	if len(m.Source.Lineages) < m.Source.Size() {
+		m.Source.NewLineages()
+	}
+
+	s := m.r.Intn(m.Source.Size())
+	d := m.r.Intn(p.Size())
+	t := SyncClocks(p, m.Source)
+	parent := m.Source.Lineages[s]
+	p.Genomes[d] = m.Source.Genomes[s].Copy()
+	m.Source.Lineages[s], p.Lineages[d] = createNewLineages(parent, t)
+
+	var a Ancestry
+	if m.Source.Ancestries != nil {
+		a = m.Source.Ancestries[s]
+		m.Source.Ancestries[s] = a.inherit(t)
+	}
+	if p.Ancestries != nil {
+		if a == nil {
+			a = NewAncestry(p.RefLength(), parent)
+		}
+		p.Ancestries[d] = a.inherit(t)
+	}
+}
+
+// MigrationMatrix stores the rates of migration,
+// in which M[i][j] is the rate per genome per generation
+// at which the genomes of the population i are replaced by migrants
+// from the population j.
+type MigrationMatrix [][]float64
+
+// NewMigrationMatrix returns a migration matrix of the rows,
+// which must be square with non-negative rates.
+// Rates on the diagonal are ignored.
+func NewMigrationMatrix(rows [][]float64) (MigrationMatrix, error) {
+	m := make(MigrationMatrix, len(rows))
+	for i, row := range rows {
+		if len(row) != len(rows) {
+			return nil, fmt.Errorf("migration matrix requires %d rates in each row, but got %d in row %d", len(rows), len(row), i)
+		}
+		m[i] = make([]float64, len(row))
+		for j, rate := range row {
+			if rate < 0 {
+				return nil, fmt.Errorf("migration matrix has a negative rate %f from %d to %d", rate, j, i)
+			}
+			if i != j {
+				m[i][j] = rate
+			}
+		}
+	}
+	return m, nil
+}
+
+// IslandMigration returns the migration matrix of the island model,
+// in which the migrants of a population come equally from all the others
+// at the total rate.
+func IslandMigration(demes int, rate float64) MigrationMatrix {
+	m := make(MigrationMatrix, demes)
+	for i := range m {
+		m[i] = make([]float64, demes)
+		for j := range m[i] {
+			if i != j {
+				m[i][j] = rate / float64(demes-1)
+			}
+		}
+	}
+	return m
+}
+
+// SteppingStoneMigration returns the migration matrix of the stepping-stone model,
+// in which the migrants of a population come equally from its neighbors
+// in a line, or in a ring if circular is true, at the total rate.
+func SteppingStoneMigration(demes int, rate float64, circular bool) MigrationMatrix {
+	m := make(MigrationMatrix, demes)
+	for i := range m {
+		m[i] = make([]float64, demes)
+		neighbors := []int{}
+		for _, j := range []int{i - 1, i + 1} {
+			if circular {
+				j = (j + demes) % demes
+			}
+			if j >= 0 && j < demes && j != i {
+				neighbors = append(neighbors, j)
+			}
+		}
+		for _, j := range neighbors {
+			// two populations in a ring are neighbors on both sides.
+			m[i][j] += rate / float64(len(neighbors))
+		}
+	}
+	return m
+}
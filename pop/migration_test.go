@@ -0,0 +1,122 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMigrationMatrix(t *testing.T) {
+	island := IslandMigration(3, 0.2)
+	if island[0][0] != 0 || math.Abs(island[0][1]-0.1) > 1e-12 || math.Abs(island[2][1]-0.1) > 1e-12 {
+		t.Errorf("Expect island rates 0.1, but got %v\n", island)
+	}
+
+	line := SteppingStoneMigration(4, 0.2, false)
+	if line[0][1] != 0.2 || line[1][0] != 0.1 || line[1][2] != 0.1 || line[0][3] != 0 {
+		t.Errorf("Expect stepping-stone rates, but got %v\n", line)
+	}
+	ring := SteppingStoneMigration(4, 0.2, true)
+	if ring[0][3] != 0.1 || ring[0][1] != 0.1 || ring[0][2] != 0 {
+		t.Errorf("Expect ring rates, but got %v\n", ring)
+	}
+	if pair := SteppingStoneMigration(2, 0.2, true); pair[0][1] != 0.2 {
+		t.Errorf("Expect the total rate from the only neighbor, but got %v\n", pair)
+	}
+
+	if _, err := NewMigrationMatrix([][]float64{{0, 1}, {1}}); err == nil {
+		t.Errorf("Expect an error for a non-square matrix\n")
+	}
+	m, err := NewMigrationMatrix([][]float64{{5, 1}, {2, 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m[0][0] != 0 || m[0][1] != 1 {
+		t.Errorf("Expect the diagonal to be ignored, but got %v\n", m)
+	}
+}
+
+func TestMigration(t *testing.T) {
+	src := rand.NewSource(1)
+	r := rand.New(src)
+	p1, p2 := New(), New()
+	NewRandomPopGenerator(r, 10, 20, []byte("A")).Operate(p1)
+	NewRandomPopGenerator(r, 15, 20, []byte("C")).Operate(p2)
+	p2.EnableARG()
+	migration := NewMigration(p1, src)
+	for i := 0; i < 5; i++ {
+		migration.Operate(p2)
+	}
+	if p1.Size() != 10 || p2.Size() != 15 || len(p2.Lineages) != 15 || len(p2.Ancestries) != 15 {
+		t.Errorf("Expect the sizes of the populations to be kept\n")
+	}
+
+	migrants := 0
+	for i, g := range p2.Genomes {
+		if g.Seq()[0] != 'A' {
+			continue
+		}
+		migrants++
+		// the migrant shares an ancestral lineage with a genome of the source.
+		parent := p2.Lineages[i].Parent
+		found := false
+		for _, l := range p1.Lineages {
+			for ; l != nil; l = l.Parent {
+				if l == parent {
+					found = true
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expect the lineage of the migrant to continue from the source\n")
+		}
+		if p2.Ancestries[i][0].Lineage.Parent != parent {
+			t.Errorf("Expect the ancestry of the migrant to follow its lineage\n")
+		}
+	}
+	if migrants == 0 {
+		t.Errorf("Expect migrants in the recipient population\n")
+	}
+}
+
+func TestMigrationClocks(t *testing.T) {
+	src := rand.NewSource(1)
+	r := rand.New(src)
+	source, p := New(), New()
+	NewRandomPopGenerator(r, 10, 20, []byte("A")).Operate(source)
+	NewRandomPopGenerator(r, 10, 20, []byte("C")).Operate(p)
+	source.EnableARG()
+	moran := NewMoranSampler(src)
+	for i := 0; i < 1000; i++ {
+		moran.Operate(source)
+	}
+
+	// the recipient is not in the ARG mode, but the source is.
+	migration := NewMigration(source, src)
+	migration.Operate(p)
+	if p.NumGeneration != source.NumGeneration {
+		t.Errorf("Expect a common clock, but got %d and %d\n", p.NumGeneration, source.NumGeneration)
+	}
+	for _, l := range append(append([]*Lineage{}, p.Lineages...), source.Lineages...) {
+		for ; l.Parent != nil; l = l.Parent {
+			if l.Parent.BirthTime > l.BirthTime {
+				t.Fatalf("Expect a parent born at %d to be older than its child born at %d\n", l.Parent.BirthTime, l.BirthTime)
+			}
+		}
+	}
+	// the ancestry of the source genome is split with its lineage.
+	p.NumGeneration = 2000
+	migration.Operate(p)
+	split := 0
+	for i, a := range source.Ancestries {
+		if a[0].Lineage.BirthTime == 2000 {
+			split++
+			if source.Lineages[i].BirthTime != 2000 {
+				t.Errorf("Expect the lineage of the source genome to split with its ancestry\n")
+			}
+		}
+	}
+	if split != 1 {
+		t.Errorf("Expect the ancestry of the source genome to split, but got %d\n", split)
+	}
+}
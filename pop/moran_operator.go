@@ -1,7 +1,6 @@
 package pop
 
 import (
-	"math"
 	"math/rand"
 
 	"github.com/mingzhi/numgo/random"
@@ -11,7 +10,11 @@ import (
 //
 // In each step of Moran process, two individuals are randomly chose:
 // one to reproduce and the other to be replaced.
+// The one to reproduce is chosen with the weights given by Fitness,
+// which is ExponentialMap if it is nil.
 type MoranSampler struct {
+	Fitness FitnessMap
+
 	rng *random.Rand // random number generator.
 	rw  *RouletteWheel
 }
@@ -38,24 +41,19 @@ func (m *MoranSampler) Operate(p *Pop) {
 	p.NumGeneration++
 
 	// random choose a going-death one
-	d := rand.Intn(p.Size())
+	d := m.rw.r.Intn(p.Size())
 	// random choose a going-birth one according to the fitness.
-	meanFit := p.MeanFit()
-	var weights []float64
-	for i := 0; i < p.Size(); i++ {
-		meanOffSpring := math.Exp(p.Genomes[i].Fitness() - meanFit)
-		// var f float64
-		// f = p.Genomes[i].Fitness()
-		// weights = append(weights, f+1.0/float64(p.Size()))
-		weights = append(weights, meanOffSpring)
-	}
-	b := m.rw.Select(weights)
+	b := m.rw.Select(fitnessWeights(m.Fitness, p))
 
 	if d != b {
 		p.Genomes[d] = p.Genomes[b].Copy()
 	}
 
 	p.Lineages[b], p.Lineages[d] = createNewLineages(p.Lineages[b], p.NumGeneration)
+	if p.Ancestries != nil {
+		a := p.Ancestries[b]
+		p.Ancestries[b], p.Ancestries[d] = a.inherit(p.NumGeneration), a.inherit(p.NumGeneration)
+	}
 }
 
 func (m *MoranSampler) Time(p *Pop) float64 {
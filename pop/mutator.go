@@ -12,6 +12,9 @@ import (
 type SimpleMutator struct {
 	// Rand is a source of random numbers
 	Alphabet []byte
+	// Sites stores the relative rates of the sites,
+	// which are equal if it is nil.
+	Sites *SiteRates
 
 	r *random.Rand
 }
@@ -28,7 +31,10 @@ func (s *SimpleMutator) Operate(p *Pop) {
 	// We need to determine randomly which genome will have a mutation.
 	// and which position on the genome.
 	g := s.r.Intn(p.Size())
-	pos := s.r.Intn(p.Length())
+	pos := samplePosition(s.Sites, s.r, p.Genomes[g])
+	if pos < 0 {
+		return
+	}
 
 	// Randomly choose a letter and replace the existed one.
 	alphabet := []byte{}
@@ -37,7 +43,10 @@ func (s *SimpleMutator) Operate(p *Pop) {
 			alphabet = append(alphabet, s.Alphabet[j])
 		}
 	}
-	p.Genomes[g].Seq()[pos] = alphabet[s.r.Intn(len(alphabet))]
+	setBase(p.Genomes[g], pos, alphabet[s.r.Intn(len(alphabet))])
+	if p.RecordMutations {
+		p.recordMutation(g, pos)
+	}
 }
 
 // BeneficialMutator is a selective mutator.
@@ -48,12 +57,22 @@ type BeneficialMutator struct {
 
 // Operate increase the fitness score by S.
 func (m *BeneficialMutator) Operate(p *Pop) {
+	if err := m.Apply(p); err != nil {
+		panic(err)
+	}
+}
+
+// Apply increase the fitness score by S,
+// and returns an error if the genome is not a FitnessUpdater.
+func (m *BeneficialMutator) Apply(p *Pop) error {
 	// randomly choose a genome.
 	g := m.r.Intn(p.Size())
-	var ag *NeutralGenome
+	ag, ok := p.Genomes[g].(FitnessUpdater)
+	if !ok {
+		return &UnsupportedGenomeError{Op: "BeneficialMutator", Genome: p.Genomes[g]}
+	}
 	// increase its number of beneficial mutation
-	ag = p.Genomes[g].(*NeutralGenome)
-	ag.fitness += m.S
+	return ag.UpdateFitness(m.S, BeneficialMutation)
 }
 
 // NewBeneficialMutator returns a new BeneficialMutator.
@@ -66,10 +85,16 @@ func NewBeneficialMutator(s float64, src rand.Source) *BeneficialMutator {
 // the fitness score by delta.
 type DeltaMutateFunc func(f *FitnessMutator) (delta float64)
 
-// FitnessMutator is a mutator on fitness score.
+// FitnessMutator is a mutator on fitness score,
+// of genomes implementing FitnessUpdater.
+//
+// If DFE is not nil, the changes of fitness are drawn from it.
+// Otherwise, they are given by the delta function,
+// and counted as beneficial or deleterious by their signs.
 type FitnessMutator struct {
 	Scale float64
 	Shape float64
+	DFE   *DFE
 	rand  *random.Rand
 	delta DeltaMutateFunc
 }
@@ -84,16 +109,43 @@ func NewFitnessMutator(scale, shape float64, src rand.Source, deltaFunc DeltaMut
 	return &f
 }
 
-func (f *FitnessMutator) mutate(p *Pop) {
+// NewDFEMutator returns a new fitness mutator
+// with the changes of fitness drawn from the DFE.
+func NewDFEMutator(dfe *DFE, src rand.Source) *FitnessMutator {
+	return &FitnessMutator{DFE: dfe, rand: random.New(src)}
+}
+
+func (f *FitnessMutator) mutate(p *Pop) error {
 	g := f.rand.Intn(p.Size())
-	var ag *NeutralGenome
-	ag = p.Genomes[g].(*NeutralGenome)
-	ag.fitness += f.delta(f)
+	ag, ok := p.Genomes[g].(FitnessUpdater)
+	if !ok {
+		return &UnsupportedGenomeError{Op: "FitnessMutator", Genome: p.Genomes[g]}
+	}
+	if f.DFE != nil {
+		class, delta := f.DFE.Sample()
+		return ag.UpdateFitness(delta, class)
+	}
+	delta := f.delta(f)
+	class := NeutralMutation
+	if delta > 0 {
+		class = BeneficialMutation
+	} else if delta < 0 {
+		class = DeleteriousMutation
+	}
+	return ag.UpdateFitness(delta, class)
 }
 
 // Operate mutate the fitness score.
 func (f *FitnessMutator) Operate(p *Pop) {
-	f.mutate(p)
+	if err := f.Apply(p); err != nil {
+		panic(err)
+	}
+}
+
+// Apply mutate the fitness score,
+// and returns an error if the genome is not a FitnessUpdater.
+func (f *FitnessMutator) Apply(p *Pop) error {
+	return f.mutate(p)
 }
 
 // FitnessMutateStep return the delta fitness.
@@ -1,8 +1,23 @@
 package pop
 
+import (
+	"math"
+	"sort"
+)
+
 type NeutralGenome struct {
 	Sequence ByteSequence
-	fitness  float64
+	// Coords maps the positions to the coordinates of the ancestral genome,
+	// which is nil if there has been no insertion or deletion.
+	Coords []int
+	// Counts stores the numbers of mutations of each class
+	// introduced by fitness mutators.
+	Counts  MutationCounts
+	fitness float64
+	// logProduct is the sum of log(1 + s) over the effects s of the mutations.
+	logProduct float64
+	// Meta stores the metadata of the genome.
+	Meta map[string]string
 }
 
 type ByteSequence []byte
@@ -19,10 +34,148 @@ func (g *NeutralGenome) Fitness() float64 {
 	return g.fitness
 }
 
+// UpdateFitness adds delta to the fitness, and counts the mutation.
+func (g *NeutralGenome) UpdateFitness(delta float64, class MutationClass) error {
+	g.fitness += delta
+	if 1+delta > 0 {
+		g.logProduct += math.Log(1 + delta)
+	} else {
+		g.logProduct = math.Inf(-1)
+	}
+	g.Counts.Add(class)
+	return nil
+}
+
+// MultiplicativeFitness returns the product of 1 + s
+// over the effects s of the mutations.
+func (g *NeutralGenome) MultiplicativeFitness() float64 {
+	return math.Exp(g.logProduct)
+}
+
+func (g *NeutralGenome) MutationCounts() MutationCounts {
+	return g.Counts
+}
+
+func (g *NeutralGenome) Metadata(key string) string {
+	return g.Meta[key]
+}
+
+func (g *NeutralGenome) SetMetadata(key, value string) {
+	if g.Meta == nil {
+		g.Meta = make(map[string]string)
+	}
+	g.Meta[key] = value
+}
+
 func (g *NeutralGenome) Copy() Genome {
 	var g1 NeutralGenome
 	g1.Sequence = make(ByteSequence, g.Length())
 	copy(g1.Sequence, g.Sequence)
+	if g.Coords != nil {
+		g1.Coords = append([]int{}, g.Coords...)
+	}
+	g1.Counts = g.Counts
 	g1.fitness = g.fitness
+	g1.logProduct = g.logProduct
+	if g.Meta != nil {
+		g1.Meta = make(map[string]string, len(g.Meta))
+		for k, v := range g.Meta {
+			g1.Meta[k] = v
+		}
+	}
 	return &g1
 }
+
+// Coord returns the ancestral coordinate of the position,
+// or a negative one if the position is inserted.
+func (g *NeutralGenome) Coord(pos int) int {
+	if g.Coords == nil {
+		return pos
+	}
+	return g.Coords[pos]
+}
+
+// Bound returns the first position whose ancestral coordinate is not less than coord,
+// where inserted positions follow their preceding ones.
+func (g *NeutralGenome) Bound(coord int) int {
+	if g.Coords == nil {
+		if coord < 0 {
+			return 0
+		} else if coord > g.Length() {
+			return g.Length()
+		}
+		return coord
+	}
+	return sort.Search(len(g.Coords), func(i int) bool {
+		for ; i >= 0; i-- {
+			if g.Coords[i] >= 0 {
+				return g.Coords[i] >= coord
+			}
+		}
+		return false
+	})
+}
+
+// Insert inserts the sequence before the position.
+func (g *NeutralGenome) Insert(pos int, seq []byte) {
+	g.insert(pos, seq, Inserted)
+}
+
+// InsertAccessory inserts the foreign sequence before the position.
+func (g *NeutralGenome) InsertAccessory(pos int, seq []byte) {
+	g.insert(pos, seq, Accessory)
+}
+
+// insert inserts the sequence with the coordinate before the position.
+func (g *NeutralGenome) insert(pos int, seq []byte, coord int) {
+	g.materialize()
+	coords := make([]int, len(seq))
+	for i := range coords {
+		coords[i] = coord
+	}
+	g.Sequence = append(g.Sequence[:pos], append(ByteSequence(append([]byte{}, seq...)), g.Sequence[pos:]...)...)
+	g.Coords = append(g.Coords[:pos], append(coords, g.Coords[pos:]...)...)
+}
+
+// Delete deletes n positions from the position.
+func (g *NeutralGenome) Delete(pos, n int) {
+	g.materialize()
+	g.Sequence = append(g.Sequence[:pos], g.Sequence[pos+n:]...)
+	g.Coords = append(g.Coords[:pos], g.Coords[pos+n:]...)
+}
+
+// Replace replaces the positions in the ancestral stretch [start, end)
+// by those of the donor, including its insertions and deletions.
+func (g *NeutralGenome) Replace(start, end int, donor IndelGenome) {
+	if g.Coords == nil && donor.Unchanged() {
+		copy(g.Sequence[start:end], donor.Seq()[start:end])
+		return
+	}
+
+	g.materialize()
+	i, j := g.Bound(start), g.Bound(end)
+	di, dj := donor.Bound(start), donor.Bound(end)
+	seq := append([]byte{}, donor.Seq()[di:dj]...)
+	coords := make([]int, 0, dj-di)
+	for k := di; k < dj; k++ {
+		coords = append(coords, donor.Coord(k))
+	}
+	g.Sequence = append(g.Sequence[:i], append(ByteSequence(seq), g.Sequence[j:]...)...)
+	g.Coords = append(g.Coords[:i], append(coords, g.Coords[j:]...)...)
+}
+
+// Unchanged reports whether there has been no insertion or deletion.
+func (g *NeutralGenome) Unchanged() bool {
+	return g.Coords == nil
+}
+
+// materialize creates the coordinates of an unchanged genome.
+func (g *NeutralGenome) materialize() {
+	if g.Coords != nil {
+		return
+	}
+	g.Coords = make([]int, g.Length())
+	for i := range g.Coords {
+		g.Coords[i] = i
+	}
+}
@@ -0,0 +1,106 @@
+package pop
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Newick returns the genealogy of the genomes at the given indices
+// in Newick format, or the genealogy of the whole population
+// if no index is given.
+//
+// Leaves are labelled by the indices of the genomes,
+// and internal nodes are labelled by the birth time of their daughters,
+// at which the lineages split. Pass-through nodes are collapsed,
+// and branch lengths are in generations,
+// so that the distance between two leaves is twice their coalescent time
+// as given by CalcT2.
+func Newick(p *Pop, indices ...int) string {
+	return newick(p.Lineages, p.NumGeneration+1, indices)
+}
+
+// newick returns the genealogy of the lineages at the indices in Newick format,
+// where the leaves are at the leaf time.
+func newick(lineages Lineages, leafTime int, indices []int) string {
+	if len(indices) == 0 {
+		for i := 0; i < len(lineages); i++ {
+			indices = append(indices, i)
+		}
+	}
+
+	g := genealogy{
+		children: make(map[*Lineage][]*Lineage),
+		leaves:   make(map[*Lineage]int),
+		leafTime: leafTime,
+		visited:  make(map[*Lineage]bool),
+	}
+	for _, i := range indices {
+		g.add(lineages[i], i)
+	}
+
+	var b bytes.Buffer
+	if len(g.leaves) > 0 {
+		g.write(&b, g.collapse(nil))
+	}
+	b.WriteString(";")
+	return b.String()
+}
+
+// genealogy stores the sub-tree of lineages leading to the leaves.
+// The nil key of children stands for the virtual root,
+// whose daughters are lineages without parents.
+type genealogy struct {
+	children map[*Lineage][]*Lineage
+	leaves   map[*Lineage]int
+	leafTime int
+	visited  map[*Lineage]bool
+}
+
+func (g *genealogy) add(leaf *Lineage, index int) {
+	g.leaves[leaf] = index
+	for l := leaf; l != nil && !g.visited[l]; l = l.Parent {
+		g.visited[l] = true
+		g.children[l.Parent] = append(g.children[l.Parent], l)
+	}
+}
+
+// collapse descends from the node through the pass-through nodes,
+// and returns the first leaf or splitting node.
+func (g *genealogy) collapse(l *Lineage) *Lineage {
+	for {
+		if _, isLeaf := g.leaves[l]; isLeaf {
+			return l
+		}
+		if len(g.children[l]) != 1 {
+			return l
+		}
+		l = g.children[l][0]
+	}
+}
+
+// time returns the time of a leaf or a splitting node.
+func (g *genealogy) time(l *Lineage) int {
+	if _, isLeaf := g.leaves[l]; isLeaf {
+		return g.leafTime
+	}
+	return g.children[l][0].BirthTime
+}
+
+func (g *genealogy) write(b *bytes.Buffer, l *Lineage) {
+	if index, isLeaf := g.leaves[l]; isLeaf {
+		fmt.Fprintf(b, "%d", index)
+		return
+	}
+
+	t := g.time(l)
+	b.WriteString("(")
+	for i, c := range g.children[l] {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		node := g.collapse(c)
+		g.write(b, node)
+		fmt.Fprintf(b, ":%d", g.time(node)-t)
+	}
+	fmt.Fprintf(b, ")%d", t)
+}
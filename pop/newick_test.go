@@ -0,0 +1,33 @@
+package pop
+
+import (
+	"testing"
+)
+
+func TestNewick(t *testing.T) {
+	root := &Lineage{}
+	a, b := createNewLineages(root, 2)
+	a1, a2 := createNewLineages(a, 5)
+	b1, _ := createNewLineages(b, 3)
+
+	p := New()
+	p.Lineages = []*Lineage{a1, a2, b1}
+	p.NumGeneration = 6
+
+	expected := "((0:2,1:2)5:3,2:5)2;"
+	if res := Newick(p); res != expected {
+		t.Errorf("Expect %s, but got %s\n", expected, res)
+	}
+
+	expected = "(0:5,2:5)2;"
+	if res := Newick(p, 0, 2); res != expected {
+		t.Errorf("Expect %s, but got %s\n", expected, res)
+	}
+
+	// lineages without parents split from a virtual root.
+	p.Lineages = []*Lineage{a1, &Lineage{}}
+	expected = "(0:7,1:7)0;"
+	if res := Newick(p); res != expected {
+		t.Errorf("Expect %s, but got %s\n", expected, res)
+	}
+}
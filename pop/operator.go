@@ -5,3 +5,10 @@ package pop
 type Operator interface {
 	Operate(*Pop)
 }
+
+// Applier is an operator that returns an error,
+// instead of panicking, if it can't operate on the population.
+type Applier interface {
+	Operator
+	Apply(*Pop) error
+}
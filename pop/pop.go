@@ -9,8 +9,20 @@ type Pop struct {
 	// Genomes stores a array of sequences
 	Genomes []Genome
 	// Circled indicates whether the genome is circled or not.
-	Circled       bool
-	Lineages      []*Lineage
+	Circled bool
+	// Ancestor is the ancestral sequence of the population,
+	// which is used to polarize mutations.
+	Ancestor ByteSequence
+	Lineages []*Lineage
+	// Ancestries stores the ancestry of segments of each genome,
+	// which is nil unless the ARG mode is enabled.
+	Ancestries []Ancestry
+	// Mutations stores the mutations carried by the lineages,
+	// which are recorded only if RecordMutations is set.
+	Mutations       []Mutation
+	RecordMutations bool
+	// Transfer counts the transfers into the population.
+	Transfer      TransferStats
 	NumGeneration int
 	TargetSize    int
 }
@@ -33,6 +45,15 @@ func (p *Pop) Length() int {
 	return p.Genomes[0].Length()
 }
 
+// RefLength returns the length of the ancestral genome,
+// in whose coordinates genomes with insertions and deletions are aligned.
+func (p *Pop) RefLength() int {
+	if len(p.Ancestor) > 0 {
+		return len(p.Ancestor)
+	}
+	return p.Length()
+}
+
 // NewLineages create new lineages.
 func (p *Pop) NewLineages() {
 	p.Lineages = make([]*Lineage, p.Size())
@@ -61,16 +82,34 @@ func (p *Pop) MaxFit() float64 {
 	return max
 }
 
+// SyncClocks sets the generations of the populations to the latest of them,
+// so that they stamp the births of lineages by a common clock,
+// and returns the common generation.
+func SyncClocks(pops ...*Pop) int {
+	t := 0
+	for _, p := range pops {
+		if p.NumGeneration > t {
+			t = p.NumGeneration
+		}
+	}
+	for _, p := range pops {
+		p.NumGeneration = t
+	}
+	return t
+}
+
 // RandomPopGenerator randomly generates a population
 // with a random neutral ancestral genome,
 // given the size of the population
 // and the length of the genome.
+// If Landscape is not nil, the genomes are SequenceGenomes on it.
 type RandomPopGenerator struct {
 	// Rand is a source of random numbers
-	Rand     Rand
-	Alphabet []byte
-	Size     int // size of population
-	Length   int // length of genome
+	Rand      Rand
+	Alphabet  []byte
+	Size      int // size of population
+	Length    int // length of genome
+	Landscape Landscape
 }
 
 // NewRandomPopGenerator return a Pop.
@@ -103,9 +142,14 @@ func (r *RandomPopGenerator) Operate(p *Pop) {
 
 	p.Genomes = make([]Genome, r.Size)
 	for i := 0; i < len(genomes); i++ {
-		p.Genomes[i] = &genomes[i]
+		if r.Landscape != nil {
+			p.Genomes[i] = NewSequenceGenome(genomes[i].Sequence, r.Landscape)
+		} else {
+			p.Genomes[i] = &genomes[i]
+		}
 	}
 
+	p.Ancestor = ancestor
 	p.TargetSize = r.Size
 	p.NewLineages()
 }
@@ -123,6 +167,7 @@ func NewSimplePopGenerator(ancestor Genome, size int) *SimplePopGenerator {
 
 // Operate copy the ancestor to all genomes.
 func (s *SimplePopGenerator) Operate(p *Pop) {
+	p.Ancestor = append(ByteSequence{}, s.Ancestor.Seq()...)
 	for i := 0; i < s.Size; i++ {
 		var g Genome
 		g = s.Ancestor.Copy()
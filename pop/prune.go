@@ -0,0 +1,115 @@
+package pop
+
+// PruneLineages simplifies the genealogy of the populations,
+// by removing the ancestors which do not split,
+// so that long chains of pass-through lineages can be garbage collected.
+//
+// It keeps the current lineages, the lineages having at least two daughters
+// with current descendants, and the daughters of them,
+// whose birth times record when the lineages split.
+// Therefore, coalescent times are left unchanged.
+//
+// Populations sharing ancestors must be pruned together.
+// In the ARG mode, the lineages of all segments are kept as well.
+// Recorded mutations on removed lineages are moved to their kept descendants,
+// and those on extinct lineages are dropped.
+func PruneLineages(pops ...*Pop) {
+	tips := Lineages{}
+	for _, p := range pops {
+		tips = append(tips, p.Lineages...)
+		for _, a := range p.Ancestries {
+			for _, s := range a {
+				tips = append(tips, s.Lineage)
+			}
+		}
+	}
+	survivors := pruneLineages(tips)
+
+	for _, p := range pops {
+		if len(p.Mutations) == 0 {
+			continue
+		}
+		mutations := p.Mutations[:0]
+		for _, m := range p.Mutations {
+			if l, found := survivors[m.Lineage]; found {
+				m.Lineage = l
+				mutations = append(mutations, m)
+			}
+		}
+		p.Mutations = mutations
+	}
+}
+
+// pruneLineages prunes the genealogy of the tips.
+// It returns the survivor of each lineage with current descendants,
+// which is the lineage itself if it is kept,
+// or otherwise its nearest kept descendant.
+func pruneLineages(tips Lineages) map[*Lineage]*Lineage {
+	isTip := make(map[*Lineage]bool)
+	visited := Lineages{}
+	seen := make(map[*Lineage]bool)
+	// number of daughters with current descendants,
+	// where the nil key stands for the virtual root.
+	numDaughters := make(map[*Lineage]int)
+	// a daughter with current descendants.
+	daughter := make(map[*Lineage]*Lineage)
+	for _, tip := range tips {
+		if tip == nil {
+			continue
+		}
+		isTip[tip] = true
+		for l := tip; l != nil && !seen[l]; l = l.Parent {
+			seen[l] = true
+			visited = append(visited, l)
+			numDaughters[l.Parent]++
+			daughter[l.Parent] = l
+		}
+	}
+
+	kept := make(map[*Lineage]bool)
+	for _, l := range visited {
+		if isTip[l] || numDaughters[l] >= 2 || numDaughters[l.Parent] >= 2 {
+			kept[l] = true
+		}
+	}
+
+	// a removed lineage has a single daughter with current descendants,
+	// which is visited before it.
+	survivors := make(map[*Lineage]*Lineage)
+	for _, l := range visited {
+		if kept[l] {
+			survivors[l] = l
+		} else {
+			survivors[l] = survivors[daughter[l]]
+		}
+	}
+
+	for _, l := range visited {
+		if !kept[l] {
+			continue
+		}
+		a := l.Parent
+		for a != nil && !kept[a] {
+			a = a.Parent
+		}
+		l.Parent = a
+	}
+
+	return survivors
+}
+
+// LineagePruner is an operator which prunes the lineages
+// of a group of populations sharing ancestors.
+type LineagePruner struct {
+	Pops []*Pop
+}
+
+// NewLineagePruner returns a new LineagePruner.
+func NewLineagePruner(pops ...*Pop) *LineagePruner {
+	return &LineagePruner{Pops: pops}
+}
+
+// Operate prunes the lineages of all the populations in the group.
+func (l *LineagePruner) Operate(p *Pop) {
+	PruneLineages(l.Pops...)
+}
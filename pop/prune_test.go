@@ -0,0 +1,54 @@
+package pop
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func countLineages(p *Pop) int {
+	seen := make(map[*Lineage]bool)
+	for _, tip := range p.Lineages {
+		for l := tip; l != nil && !seen[l]; l = l.Parent {
+			seen[l] = true
+		}
+	}
+	return len(seen)
+}
+
+func TestPruneLineages(t *testing.T) {
+	p := runMoran(20, 5000, 1)
+	t2s := CalcAllT2(p)
+	tmrca := CalcTMRCA(p)
+	newick := Newick(p)
+	numLineages := countLineages(p)
+
+	PruneLineages(p)
+
+	if n := countLineages(p); n >= numLineages || n > 4*p.Size() {
+		t.Errorf("Expect pruning to reduce %d lineages, but got %d\n", numLineages, n)
+	}
+	for i, v := range CalcAllT2(p) {
+		if v != t2s[i] {
+			t.Errorf("Expect T2 %f, but got %f\n", t2s[i], v)
+		}
+	}
+	if v := CalcTMRCA(p); v != tmrca {
+		t.Errorf("Expect TMRCA %f, but got %f\n", tmrca, v)
+	}
+	if s := Newick(p); s != newick {
+		t.Errorf("Expect %s, but got %s\n", newick, s)
+	}
+
+	// continue evolving the pruned population.
+	moran := NewMoranSampler(rand.NewSource(2))
+	for i := 0; i < 1000; i++ {
+		moran.Operate(p)
+	}
+	t2s = CalcAllT2(p)
+	PruneLineages(p)
+	for i, v := range CalcAllT2(p) {
+		if v != t2s[i] {
+			t.Errorf("Expect T2 %f, but got %f\n", t2s[i], v)
+		}
+	}
+}
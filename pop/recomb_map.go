@@ -0,0 +1,118 @@
+package pop
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MapRegion is a stretch [Start, End) of a genome with a relative rate.
+type MapRegion struct {
+	Start, End int
+	Rate       float64
+}
+
+// RecombinationMap is a piecewise-constant relative rate along a genome,
+// which biases where transfers start.
+// It covers the whole genome, in which the positions
+// outside the given regions have the relative rate 1.
+type RecombinationMap struct {
+	Regions []MapRegion
+
+	cum []float64 // cumulative weights of the regions.
+}
+
+// NewRecombinationMap returns a recombination map of a genome of the length,
+// given the regions of hotspots and coldspots, which must not overlap.
+func NewRecombinationMap(length int, regions []MapRegion) (*RecombinationMap, error) {
+	sorted := append([]MapRegion{}, regions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	m := RecombinationMap{}
+	pos := 0
+	for _, r := range sorted {
+		if r.Start < pos || r.End <= r.Start || r.End > length {
+			return nil, fmt.Errorf("invalid region [%d, %d) of a genome of length %d", r.Start, r.End, length)
+		}
+		if r.Rate < 0 {
+			return nil, fmt.Errorf("region [%d, %d) has a negative rate %f", r.Start, r.End, r.Rate)
+		}
+		if r.Start > pos {
+			m.Regions = append(m.Regions, MapRegion{Start: pos, End: r.Start, Rate: 1})
+		}
+		m.Regions = append(m.Regions, r)
+		pos = r.End
+	}
+	if pos < length {
+		m.Regions = append(m.Regions, MapRegion{Start: pos, End: length, Rate: 1})
+	}
+
+	total := 0.0
+	for _, r := range m.Regions {
+		total += r.Rate * float64(r.End-r.Start)
+		m.cum = append(m.cum, total)
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("recombination map has no positive rate")
+	}
+	return &m, nil
+}
+
+// ReadRecombinationMap reads the regions of a recombination map in a BED-like format,
+// in which each line has the start, the end and the relative rate of a region,
+// optionally preceded by the name of the sequence.
+// Empty lines and lines starting with # are ignored.
+func ReadRecombinationMap(r io.Reader, length int) (*RecombinationMap, error) {
+	regions := []MapRegion{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 4 {
+			fields = fields[1:]
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("expect 3 or 4 columns in the recombination map, but got %q", line)
+		}
+		start, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		rate, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, err
+		}
+		regions = append(regions, MapRegion{Start: start, End: end, Rate: rate})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewRecombinationMap(length, regions)
+}
+
+// Rate returns the relative rate at the position.
+func (m *RecombinationMap) Rate(pos int) float64 {
+	i := sort.Search(len(m.Regions), func(i int) bool { return m.Regions[i].End > pos })
+	if i == len(m.Regions) {
+		return 0
+	}
+	return m.Regions[i].Rate
+}
+
+// Sample returns a position chosen according to the relative rates.
+func (m *RecombinationMap) Sample(r Rand) int {
+	v := r.Float64() * m.cum[len(m.cum)-1]
+	i := sort.Search(len(m.cum), func(i int) bool { return m.cum[i] > v })
+	region := m.Regions[i]
+	return region.Start + r.Intn(region.End-region.Start)
+}
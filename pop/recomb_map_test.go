@@ -0,0 +1,42 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestRecombinationMap(t *testing.T) {
+	bed := "# chrom start end rate\nchr 10 20 9\n30 40 0\n"
+	m, err := ReadRecombinationMap(strings.NewReader(bed), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Regions) != 5 {
+		t.Errorf("Expect 5 regions, but got %d\n", len(m.Regions))
+	}
+	for pos, rate := range map[int]float64{0: 1, 15: 9, 25: 1, 35: 0, 49: 1} {
+		if v := m.Rate(pos); v != rate {
+			t.Errorf("Expect rate %f at %d, but got %f\n", rate, pos, v)
+		}
+	}
+
+	// total weight is 10 + 90 + 10 + 0 + 10 = 120.
+	r := rand.New(rand.NewSource(1))
+	n := 120000
+	counts := make(map[float64]int)
+	for i := 0; i < n; i++ {
+		counts[m.Rate(m.Sample(r))]++
+	}
+	if v := float64(counts[9]) / float64(n); math.Abs(v-0.75) > 0.01 {
+		t.Errorf("Expect 0.75 of starts in the hotspot, but got %f\n", v)
+	}
+	if counts[0] != 0 {
+		t.Errorf("Expect no start in the coldspot, but got %d\n", counts[0])
+	}
+
+	if _, err := NewRecombinationMap(50, []MapRegion{{0, 20, 2}, {10, 30, 2}}); err == nil {
+		t.Errorf("Expect error for overlapping regions\n")
+	}
+}
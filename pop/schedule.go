@@ -0,0 +1,225 @@
+package pop
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mingzhi/numgo/random"
+)
+
+// SizeSchedule gives the size of a population at a time in generations.
+type SizeSchedule interface {
+	Size(t float64) int
+}
+
+// StepSchedule is a piecewise-constant schedule,
+// in which the size changes to Sizes[i] at Times[i],
+// and is Initial before Times[0].
+type StepSchedule struct {
+	Initial int
+	Times   []float64
+	Sizes   []int
+}
+
+// NewStepSchedule returns a new StepSchedule,
+// given the times of the changes in increasing order.
+func NewStepSchedule(initial int, times []float64, sizes []int) (*StepSchedule, error) {
+	if len(times) != len(sizes) {
+		return nil, fmt.Errorf("step schedule requires the same numbers of times and sizes, but got %d and %d", len(times), len(sizes))
+	}
+	if !sort.Float64sAreSorted(times) {
+		return nil, fmt.Errorf("step schedule requires times in increasing order")
+	}
+	for _, n := range append([]int{initial}, sizes...) {
+		if n < 1 {
+			return nil, fmt.Errorf("step schedule requires positive sizes, but got %d", n)
+		}
+	}
+	return &StepSchedule{Initial: initial, Times: times, Sizes: sizes}, nil
+}
+
+func (s *StepSchedule) Size(t float64) int {
+	i := sort.Search(len(s.Times), func(i int) bool { return s.Times[i] > t })
+	if i == 0 {
+		return s.Initial
+	}
+	return s.Sizes[i-1]
+}
+
+// ReadSizeSchedule reads a StepSchedule from a table,
+// in which each line has a time and the size from the time on.
+// Empty lines and lines starting with # are ignored.
+func ReadSizeSchedule(r io.Reader, initial int) (*StepSchedule, error) {
+	times := []float64{}
+	sizes := []int{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("expect 2 columns in the size schedule, but got %q", line)
+		}
+		t, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+		sizes = append(sizes, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewStepSchedule(initial, times, sizes)
+}
+
+// ExponentialSchedule grows (or declines if Rate is negative)
+// exponentially from Initial at the rate per generation
+// between Start and End, and stays constant otherwise.
+type ExponentialSchedule struct {
+	Initial    int
+	Rate       float64
+	Start, End float64
+}
+
+func (s *ExponentialSchedule) Size(t float64) int {
+	t = math.Min(math.Max(t, s.Start), s.End)
+	return clampSize(float64(s.Initial) * math.Exp(s.Rate*(t-s.Start)))
+}
+
+// PeriodicSchedule oscillates around Mean,
+// with the relative amplitude and the period in generations.
+type PeriodicSchedule struct {
+	Mean      int
+	Amplitude float64
+	Period    float64
+}
+
+func (s *PeriodicSchedule) Size(t float64) int {
+	return clampSize(float64(s.Mean) * (1 + s.Amplitude*math.Sin(2*math.Pi*t/s.Period)))
+}
+
+// clampSize rounds a size to a positive integer.
+func clampSize(n float64) int {
+	if n < 1 {
+		return 1
+	}
+	if n > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(math.Floor(n + 0.5))
+}
+
+// ScheduleParams stores the parameters of size schedules.
+type ScheduleParams struct {
+	Name string
+	// Times and Sizes of step changes.
+	Times []float64
+	Sizes []int
+	// Rate of exponential changes between Start and End.
+	Rate       float64
+	Start, End float64
+	// relative Amplitude and Period of oscillations.
+	Amplitude float64
+	Period    float64
+	// File of a table of step changes.
+	File string
+}
+
+// NewSizeSchedule returns a size schedule of a population of the initial size,
+// which is one of step, exponential, periodic and table,
+// or nil without a name.
+func NewSizeSchedule(initial int, params ScheduleParams) (SizeSchedule, error) {
+	switch params.Name {
+	case "":
+		return nil, nil
+	case "step":
+		return NewStepSchedule(initial, params.Times, params.Sizes)
+	case "exponential":
+		if params.End < params.Start {
+			return nil, fmt.Errorf("exponential schedule requires start <= end, but got %f and %f", params.Start, params.End)
+		}
+		return &ExponentialSchedule{Initial: initial, Rate: params.Rate, Start: params.Start, End: params.End}, nil
+	case "periodic":
+		if params.Period <= 0 || params.Amplitude < 0 {
+			return nil, fmt.Errorf("periodic schedule requires a positive period and a non-negative amplitude, but got %f and %f", params.Period, params.Amplitude)
+		}
+		return &PeriodicSchedule{Mean: initial, Amplitude: params.Amplitude, Period: params.Period}, nil
+	case "table":
+		f, err := os.Open(params.File)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ReadSizeSchedule(f, initial)
+	}
+	return nil, fmt.Errorf("unknown size schedule %s", params.Name)
+}
+
+// Resizer changes the size of a population to Size,
+// by births of copies of random genomes,
+// or deaths of random genomes.
+type Resizer struct {
+	Size int
+
+	r *random.Rand
+}
+
+// NewResizer returns a new Resizer.
+func NewResizer(size int, src rand.Source) *Resizer {
+	return &Resizer{Size: size, r: random.New(src)}
+}
+
+// Operate sets the target size, and changes the size of the population to it.
+func (r *Resizer) Operate(p *Pop) {
+	p.TargetSize = r.Size
+	if len(p.Lineages) < p.Size() {
+		p.NewLineages()
+	}
+	for p.Size() < r.Size && p.Size() > 0 {
+		p.birth(r.r.Intn(p.Size()))
+	}
+	for p.Size() > r.Size {
+		p.death(r.r.Intn(p.Size()))
+	}
+}
+
+// birth adds a copy of the genome i,
+// while both copies start new lineages.
+func (p *Pop) birth(i int) {
+	p.Genomes = append(p.Genomes, p.Genomes[i].Copy())
+	a, b := createNewLineages(p.Lineages[i], p.NumGeneration)
+	p.Lineages[i] = a
+	p.Lineages = append(p.Lineages, b)
+	if p.Ancestries != nil {
+		ancestry := p.Ancestries[i]
+		p.Ancestries[i] = ancestry.inherit(p.NumGeneration)
+		p.Ancestries = append(p.Ancestries, ancestry.inherit(p.NumGeneration))
+	}
+}
+
+// death removes the genome i, replacing it by the last one.
+func (p *Pop) death(i int) {
+	last := p.Size() - 1
+	p.Genomes[i] = p.Genomes[last]
+	p.Genomes = p.Genomes[:last]
+	p.Lineages[i] = p.Lineages[last]
+	p.Lineages = p.Lineages[:last]
+	if p.Ancestries != nil {
+		p.Ancestries[i] = p.Ancestries[last]
+		p.Ancestries = p.Ancestries[:last]
+	}
+}
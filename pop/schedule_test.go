@@ -0,0 +1,67 @@
+package pop
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSizeSchedules(t *testing.T) {
+	step, err := ReadSizeSchedule(strings.NewReader("# time size\n10 50\n20 200\n"), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for time, size := range map[float64]int{0: 100, 10: 50, 15: 50, 20: 200, 100: 200} {
+		if n := step.Size(time); n != size {
+			t.Errorf("Expect size %d at %f, but got %d\n", size, time, n)
+		}
+	}
+	if _, err := NewStepSchedule(100, []float64{2, 1}, []int{10, 20}); err == nil {
+		t.Errorf("Expect an error for unsorted times\n")
+	}
+
+	exp, err := NewSizeSchedule(100, ScheduleParams{Name: "exponential", Rate: -0.1, Start: 10, End: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for time, size := range map[float64]int{0: 100, 10: 100, 17: 50, 20: 37, 30: 37} {
+		if n := exp.Size(time); n != size {
+			t.Errorf("Expect size %d at %f, but got %d\n", size, time, n)
+		}
+	}
+
+	periodic, err := NewSizeSchedule(100, ScheduleParams{Name: "periodic", Amplitude: 0.5, Period: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for time, size := range map[float64]int{0: 100, 10: 150, 20: 100, 30: 50} {
+		if n := periodic.Size(time); n != size {
+			t.Errorf("Expect size %d at %f, but got %d\n", size, time, n)
+		}
+	}
+
+	if s, err := NewSizeSchedule(100, ScheduleParams{}); s != nil || err != nil {
+		t.Errorf("Expect no schedule without a name\n")
+	}
+}
+
+func TestResizer(t *testing.T) {
+	src := rand.NewSource(1)
+	p := New()
+	NewRandomPopGenerator(rand.New(src), 20, 50, []byte("ACGT")).Operate(p)
+	p.EnableARG()
+	moran := NewMoranSampler(src)
+	for _, size := range []int{80, 5, 30} {
+		NewResizer(size, src).Operate(p)
+		if p.Size() != size || len(p.Lineages) != size || len(p.Ancestries) != size || p.TargetSize != size {
+			t.Errorf("Expect population size %d, but got %d genomes, %d lineages and %d ancestries\n",
+				size, p.Size(), len(p.Lineages), len(p.Ancestries))
+		}
+		for i := 0; i < 100; i++ {
+			moran.Operate(p)
+		}
+	}
+	if tmrca := CalcTMRCA(p); tmrca <= 0 {
+		t.Errorf("Expect a positive TMRCA after resizing, but got %f\n", tmrca)
+	}
+}
@@ -7,7 +7,15 @@ import (
 	"sync"
 )
 
+// LinearSelectionSampler produces Poisson numbers of offspring,
+// regulating the population size to TargetSize.
+//
+// The mean number of offspring is the weight given by Fitness,
+// which is ExponentialMap if it is nil, relative to the mean weight,
+// multiplied by exp(1 - N/TargetSize).
 type LinearSelectionSampler struct {
+	Fitness FitnessMap
+
 	rand *random.Rand
 	wg   sync.WaitGroup
 }
@@ -21,17 +29,25 @@ func NewLinearSelectionSampler(src rand.Source) *LinearSelectionSampler {
 func (w *LinearSelectionSampler) Operate(p *Pop) {
 	defer w.wg.Done()
 
-	meanFit := p.MeanFit()
 	sizeRatio := float64(p.Size()) / float64(p.TargetSize)
-	// chemical potensial regulating the population size.
-	cpot := meanFit - (1.0 - sizeRatio)
 	currentGenomes := p.Genomes
 	currentLineages := p.Lineages
+	weights := fitnessWeights(w.Fitness, p)
+	meanWeight := 0.0
+	for _, v := range weights {
+		meanWeight += v
+	}
+	meanWeight /= float64(len(weights))
 	newGenomes := []Genome{}
 	newLineages := []*Lineage{}
+	var newAncestries []Ancestry
 	numGeneration := p.NumGeneration + 1
 	for i := 0; i < p.Size(); i++ {
-		meanOffSpring := math.Exp(p.Genomes[i].Fitness() - cpot)
+		// the size ratio regulates the population size.
+		meanOffSpring := 0.0
+		if meanWeight > 0 {
+			meanOffSpring = weights[i] / meanWeight * math.Exp(1-sizeRatio)
+		}
 		numOffSpring := int(w.rand.PoissonInt64(meanOffSpring))
 		for o := 0; o < numOffSpring; o++ {
 			var g Genome
@@ -45,10 +61,16 @@ func (w *LinearSelectionSampler) Operate(p *Pop) {
 			l.BirthTime = numGeneration
 			l.Parent = currentLineages[i]
 			newLineages = append(newLineages, l)
+			if p.Ancestries != nil {
+				newAncestries = append(newAncestries, p.Ancestries[i].inherit(numGeneration))
+			}
 		}
 	}
 	p.Genomes = newGenomes
 	p.Lineages = newLineages
+	if p.Ancestries != nil {
+		p.Ancestries = newAncestries
+	}
 	p.NumGeneration = numGeneration
 }
 
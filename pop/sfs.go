@@ -0,0 +1,215 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Neutrality stores the site frequency spectrum of a sample
+// and the classic neutrality statistics derived from it.
+//
+// Statistics which are undefined for the sample
+// (for example, without segregating sites) are set to zero.
+type Neutrality struct {
+	SampleSize int
+	// SFS is the unfolded spectrum relative to the ancestor:
+	// SFS[i] is the number of sites with i derived alleles.
+	SFS []int
+	// FoldedSFS[i] is the number of sites with minor allele count of i.
+	FoldedSFS []int
+	// Segregating is the number of segregating sites.
+	Segregating int
+
+	ThetaW  float64 // Watterson's theta.
+	Pi      float64 // Tajima's pi.
+	ThetaH  float64 // Fay and Wu's theta H.
+	TajimaD float64 // Tajima's D.
+	FayWuH  float64 // Fay and Wu's H.
+	FuLiD   float64 // Fu and Li's D.
+}
+
+// CalcNeutrality calculates the site frequency spectra
+// and neutrality statistics of a random sample from the population.
+// The unfolded spectrum, theta H, H and Fu and Li's D require p.Ancestor.
+func CalcNeutrality(sampleSize int, src rand.Source, p *Pop) Neutrality {
+	sample := SampleGenomes(sampleSize, src, p)
+	seqs := [][]byte{}
+	for _, g := range sample {
+		seqs = append(seqs, AlignedSeq(g, p.RefLength()))
+	}
+
+	var nt Neutrality
+	nt.SampleSize = len(seqs)
+	nt.FoldedSFS = FoldedSFS(seqs)
+	for i := 1; i < len(nt.FoldedSFS); i++ {
+		nt.Segregating += nt.FoldedSFS[i]
+	}
+	nt.ThetaW = wattersonTheta(nt.FoldedSFS, nt.SampleSize)
+	nt.Pi = tajimaPi(nt.FoldedSFS, nt.SampleSize)
+	nt.TajimaD = tajimaD(nt.Pi, nt.ThetaW, nt.Segregating, nt.SampleSize)
+
+	if len(p.Ancestor) > 0 {
+		nt.SFS = UnfoldedSFS(seqs, p.Ancestor)
+		nt.ThetaH = fayWuTheta(nt.SFS, nt.SampleSize)
+		nt.FayWuH = nt.Pi - nt.ThetaH
+		nt.FuLiD = fuLiD(nt.SFS, nt.SampleSize)
+	}
+
+	return nt
+}
+
+// SampleGenomes randomly samples genomes from the population without replacement.
+// It returns all genomes if the sample size is not less than the population size.
+func SampleGenomes(sampleSize int, src rand.Source, p *Pop) []Genome {
+	if sampleSize >= p.Size() {
+		return append([]Genome{}, p.Genomes...)
+	}
+	r := rand.New(src)
+	sample := []Genome{}
+	for _, i := range r.Perm(p.Size())[:sampleSize] {
+		sample = append(sample, p.Genomes[i])
+	}
+	return sample
+}
+
+// UnfoldedSFS returns the site frequency spectrum
+// of derived alleles relative to the ancestral sequence.
+// The spectrum has length of n+1 for a sample of n sequences.
+func UnfoldedSFS(seqs [][]byte, ancestor []byte) []int {
+	n := len(seqs)
+	sfs := make([]int, n+1)
+	for pos := 0; pos < len(ancestor); pos++ {
+		derived := 0
+		for _, s := range seqs {
+			if s[pos] != ancestor[pos] {
+				derived++
+			}
+		}
+		sfs[derived]++
+	}
+	return sfs
+}
+
+// FoldedSFS returns the site frequency spectrum of minor alleles,
+// whose count at a site is the sample size
+// minus the count of the major allele.
+// The spectrum has length of n/2+1 for a sample of n sequences.
+func FoldedSFS(seqs [][]byte) []int {
+	n := len(seqs)
+	sfs := make([]int, n/2+1)
+	if n == 0 {
+		return sfs
+	}
+	counts := make(map[byte]int)
+	for pos := 0; pos < len(seqs[0]); pos++ {
+		for k := range counts {
+			delete(counts, k)
+		}
+		major := 0
+		for _, s := range seqs {
+			counts[s[pos]]++
+			if counts[s[pos]] > major {
+				major = counts[s[pos]]
+			}
+		}
+		minor := n - major
+		if minor > n/2 {
+			minor = n / 2
+		}
+		sfs[minor]++
+	}
+	return sfs
+}
+
+// harmonic returns a1 = sum(1/i) and a2 = sum(1/i^2), for i from 1 to n-1.
+func harmonic(n int) (a1, a2 float64) {
+	for i := 1; i < n; i++ {
+		a1 += 1.0 / float64(i)
+		a2 += 1.0 / float64(i*i)
+	}
+	return
+}
+
+// numPairsOf returns the number of pairs in a sample of n.
+func numPairsOf(n int) float64 {
+	return float64(n*(n-1)) / 2.0
+}
+
+// wattersonTheta calculates Watterson's theta from a spectrum
+// (either folded or unfolded), ignoring the monomorphic sites.
+func wattersonTheta(sfs []int, n int) float64 {
+	a1, _ := harmonic(n)
+	if a1 == 0 {
+		return 0
+	}
+	s := 0
+	for i := 1; i < len(sfs) && i < n; i++ {
+		s += sfs[i]
+	}
+	return float64(s) / a1
+}
+
+// tajimaPi calculates the mean number of pairwise differences
+// from a spectrum (either folded or unfolded).
+func tajimaPi(sfs []int, n int) float64 {
+	if n < 2 {
+		return 0
+	}
+	var pi float64
+	for i := 1; i < len(sfs) && i < n; i++ {
+		pi += float64(i*(n-i)) * float64(sfs[i])
+	}
+	return pi / numPairsOf(n)
+}
+
+// fayWuTheta calculates theta H from an unfolded spectrum.
+func fayWuTheta(sfs []int, n int) float64 {
+	if n < 2 {
+		return 0
+	}
+	var h float64
+	for i := 1; i < n; i++ {
+		h += float64(i*i) * float64(sfs[i])
+	}
+	return h / numPairsOf(n)
+}
+
+// tajimaD calculates Tajima's D.
+func tajimaD(pi, thetaW float64, s, n int) float64 {
+	if n < 4 || s == 0 {
+		return 0
+	}
+	a1, a2 := harmonic(n)
+	nf := float64(n)
+	b1 := (nf + 1) / (3 * (nf - 1))
+	b2 := 2 * (nf*nf + nf + 3) / (9 * nf * (nf - 1))
+	c1 := b1 - 1/a1
+	c2 := b2 - (nf+2)/(a1*nf) + a2/(a1*a1)
+	e1 := c1 / a1
+	e2 := c2 / (a1*a1 + a2)
+	sf := float64(s)
+	return (pi - thetaW) / math.Sqrt(e1*sf+e2*sf*(sf-1))
+}
+
+// fuLiD calculates Fu and Li's D with an outgroup,
+// taking singletons of derived alleles as external mutations.
+func fuLiD(sfs []int, n int) float64 {
+	if n < 4 {
+		return 0
+	}
+	eta := 0
+	for i := 1; i < n; i++ {
+		eta += sfs[i]
+	}
+	if eta == 0 {
+		return 0
+	}
+	etaE := float64(sfs[1])
+	a1, a2 := harmonic(n)
+	nf := float64(n)
+	cn := 2 * (nf*a1 - 2*(nf-1)) / ((nf - 1) * (nf - 2))
+	vd := 1 + a1*a1/(a2+a1*a1)*(cn-(nf+1)/(nf-1))
+	ud := a1 - 1 - vd
+	ef := float64(eta)
+	return (ef - a1*etaE) / math.Sqrt(ud*ef+vd*ef*ef)
+}
@@ -0,0 +1,69 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCalcNeutrality(t *testing.T) {
+	tolerance := 1e-8
+	p := New()
+	p.Ancestor = ByteSequence("AAAAA")
+	for _, s := range []string{"AAAAA", "TAAAA", "TTAAA", "TTTAA"} {
+		p.Genomes = append(p.Genomes, &NeutralGenome{Sequence: ByteSequence(s)})
+	}
+
+	nt := CalcNeutrality(10, rand.NewSource(1), p)
+
+	expSFS := []int{2, 1, 1, 1, 0}
+	expFolded := []int{2, 2, 1}
+	if len(nt.SFS) != len(expSFS) {
+		t.Fatalf("Expect SFS %v, but got %v\n", expSFS, nt.SFS)
+	}
+	for i := range expSFS {
+		if nt.SFS[i] != expSFS[i] {
+			t.Errorf("Expect SFS %v, but got %v\n", expSFS, nt.SFS)
+		}
+	}
+	if len(nt.FoldedSFS) != len(expFolded) {
+		t.Fatalf("Expect folded SFS %v, but got %v\n", expFolded, nt.FoldedSFS)
+	}
+	for i := range expFolded {
+		if nt.FoldedSFS[i] != expFolded[i] {
+			t.Errorf("Expect folded SFS %v, but got %v\n", expFolded, nt.FoldedSFS)
+		}
+	}
+
+	expected := map[string][2]float64{
+		"ThetaW":  {nt.ThetaW, 18.0 / 11.0},
+		"Pi":      {nt.Pi, 10.0 / 6.0},
+		"ThetaH":  {nt.ThetaH, 14.0 / 6.0},
+		"FayWuH":  {nt.FayWuH, -4.0 / 6.0},
+		"TajimaD": {nt.TajimaD, 0.16765579503},
+		"FuLiD":   {nt.FuLiD, 0.64414539623},
+	}
+	for name, v := range expected {
+		if math.Abs(v[0]-v[1]) > tolerance {
+			t.Errorf("Expect %s %f, but got %f\n", name, v[1], v[0])
+		}
+	}
+}
+
+func TestCalcNeutralityMonomorphic(t *testing.T) {
+	p := New()
+	p.Ancestor = ByteSequence("AAAA")
+	for i := 0; i < 5; i++ {
+		p.Genomes = append(p.Genomes, &NeutralGenome{Sequence: ByteSequence("AAAA")})
+	}
+	nt := CalcNeutrality(3, rand.NewSource(1), p)
+	if nt.SampleSize != 3 {
+		t.Errorf("Expect sample size %d, but got %d\n", 3, nt.SampleSize)
+	}
+	values := []float64{nt.ThetaW, nt.Pi, nt.ThetaH, nt.TajimaD, nt.FayWuH, nt.FuLiD}
+	for _, v := range values {
+		if v != 0 || math.IsNaN(v) {
+			t.Errorf("Expect zero statistics without segregating sites, but got %v\n", values)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package pop
 
 import (
+	"bytes"
+	"fmt"
+	"math"
 	"math/rand"
+	"sort"
 )
 
 // Shock is an interface for challenging population with shocks.
@@ -12,28 +16,60 @@ type Shock interface {
 // Dilution reduces the population by certain poportion.
 type Dilution struct {
 	Factor float64
+	rand   *rand.Rand
+}
+
+// NewDilution returns a Dilution by the factor,
+// which samples the survivors from the random source.
+func NewDilution(factor float64, src rand.Source) Dilution {
+	return Dilution{Factor: factor, rand: rand.New(src)}
 }
 
 // Reduce reduces the population to certain poportion.
 func (d Dilution) Reduce(p *Pop) *Pop {
 	finalSize := int(float64(p.Size()) * d.Factor)
-	indices := make([]int, p.Size())
-	for i := 0; i < p.Size(); i++ {
-		indices[i] = i
+	var indices []int
+	if d.rand != nil {
+		indices = d.rand.Perm(p.Size())
+	} else {
+		indices = make([]int, p.Size())
+		for i := 0; i < p.Size(); i++ {
+			indices[i] = i
+		}
+		shuffle(indices)
+	}
+	finalP := p.survive(indices[:finalSize])
+	finalP.NumGeneration = 0
+	return finalP
+}
+
+// survive returns a population of the genomes at the indices,
+// which keep their lineages and ancestries.
+func (p *Pop) survive(indices []int) *Pop {
+	if len(p.Lineages) < p.Size() {
+		p.NewLineages()
 	}
-	shuffle(indices)
 	var finalGenomes []Genome
 	var finalLineages []*Lineage
-	for i := 0; i < finalSize; i++ {
-		finalGenomes = append(finalGenomes, p.Genomes[indices[i]])
-		finalLineages = append(finalLineages, p.Lineages[indices[i]])
+	var finalAncestries []Ancestry
+	for _, i := range indices {
+		finalGenomes = append(finalGenomes, p.Genomes[i])
+		finalLineages = append(finalLineages, p.Lineages[i])
+		if p.Ancestries != nil {
+			finalAncestries = append(finalAncestries, p.Ancestries[i])
+		}
 	}
 
 	finalP := Pop{}
 	finalP.Circled = p.Circled
 	finalP.Genomes = finalGenomes
 	finalP.Lineages = finalLineages
-	finalP.NumGeneration = 0
+	finalP.Ancestries = finalAncestries
+	finalP.Ancestor = p.Ancestor
+	finalP.Mutations = append([]Mutation{}, p.Mutations...)
+	finalP.RecordMutations = p.RecordMutations
+	finalP.Transfer = p.Transfer
+	finalP.NumGeneration = p.NumGeneration
 	finalP.TargetSize = p.TargetSize
 
 	return &finalP
@@ -47,6 +83,170 @@ func shuffle(a []int) {
 	}
 }
 
+// AlleleShock kills genomes without the Allele at the Site,
+// each with the probability Efficacy,
+// like an antibiotic to which the allele gives resistance.
+// The Site is an ancestral coordinate, which genomes with it deleted lack.
+type AlleleShock struct {
+	Site     int
+	Allele   byte
+	Efficacy float64
+
+	r *rand.Rand
+}
+
+// NewAlleleShock returns a new AlleleShock.
+func NewAlleleShock(site int, allele byte, efficacy float64, src rand.Source) *AlleleShock {
+	return &AlleleShock{Site: site, Allele: allele, Efficacy: efficacy, r: rand.New(src)}
+}
+
+// Reduce kills the genomes without the allele.
+func (a *AlleleShock) Reduce(p *Pop) *Pop {
+	return kill(p, a.r, a.Efficacy, func(g Genome) bool {
+		if a.Site < 0 {
+			return false
+		}
+		pos := genomePosition(g, a.Site)
+		return pos >= 0 && pos < g.Length() && g.Seq()[pos] == a.Allele
+	})
+}
+
+// MotifShock kills genomes without the Motif in their sequences,
+// each with the probability Efficacy.
+type MotifShock struct {
+	Motif    []byte
+	Efficacy float64
+
+	r *rand.Rand
+}
+
+// NewMotifShock returns a new MotifShock.
+func NewMotifShock(motif []byte, efficacy float64, src rand.Source) *MotifShock {
+	return &MotifShock{Motif: motif, Efficacy: efficacy, r: rand.New(src)}
+}
+
+// Reduce kills the genomes without the motif.
+func (m *MotifShock) Reduce(p *Pop) *Pop {
+	return kill(p, m.r, m.Efficacy, func(g Genome) bool {
+		return bytes.Contains(g.Seq(), m.Motif)
+	})
+}
+
+// kill returns the population of the resistant genomes,
+// and of the others which survive the probability of being killed.
+func kill(p *Pop, r *rand.Rand, prob float64, resistant func(g Genome) bool) *Pop {
+	indices := []int{}
+	for i, g := range p.Genomes {
+		if resistant(g) || r.Float64() >= prob {
+			indices = append(indices, i)
+		}
+	}
+	return p.survive(indices)
+}
+
+// FitnessShock keeps the Factor of the population,
+// sampling the survivors without replacement
+// with the weights given by Fitness.
+type FitnessShock struct {
+	Fitness FitnessMap
+	Factor  float64
+
+	r *rand.Rand
+}
+
+// NewFitnessShock returns a new FitnessShock.
+func NewFitnessShock(fitness FitnessMap, factor float64, src rand.Source) *FitnessShock {
+	return &FitnessShock{Fitness: fitness, Factor: factor, r: rand.New(src)}
+}
+
+// Reduce reduces the population to the survivors.
+func (f *FitnessShock) Reduce(p *Pop) *Pop {
+	finalSize := int(float64(p.Size()) * f.Factor)
+	weights := f.Fitness.Weights(p)
+	// a genome of weight w survives ahead of others by the key log(u)/w,
+	// and those of zero weight follow in a random order.
+	indices := f.r.Perm(p.Size())
+	keys := make([]float64, p.Size())
+	for i, w := range weights {
+		if w > 0 {
+			keys[i] = math.Log(f.r.Float64()) / w
+		} else {
+			keys[i] = math.Inf(-1)
+		}
+	}
+	sort.SliceStable(indices, func(a, b int) bool { return keys[indices[a]] > keys[indices[b]] })
+	return p.survive(indices[:finalSize])
+}
+
+// Catastrophe kills each genome with a probability of its severity,
+// which is drawn uniformly between MinSeverity and MaxSeverity at each shock.
+type Catastrophe struct {
+	MinSeverity, MaxSeverity float64
+
+	r *rand.Rand
+}
+
+// NewCatastrophe returns a new Catastrophe.
+func NewCatastrophe(minSeverity, maxSeverity float64, src rand.Source) *Catastrophe {
+	return &Catastrophe{MinSeverity: minSeverity, MaxSeverity: maxSeverity, r: rand.New(src)}
+}
+
+// Reduce kills the genomes at a random severity.
+func (c *Catastrophe) Reduce(p *Pop) *Pop {
+	severity := c.MinSeverity + (c.MaxSeverity-c.MinSeverity)*c.r.Float64()
+	return kill(p, c.r, severity, func(Genome) bool { return false })
+}
+
+// ShockParams stores the parameters of shocks.
+type ShockParams struct {
+	Name string
+	// Site and Allele of resistance to allele shocks,
+	// and Motif of resistance to motif shocks,
+	// which kill the others with the probability Efficacy.
+	Site     int
+	Allele   string
+	Motif    string
+	Efficacy float64
+	// Factor of the population surviving fitness shocks.
+	Factor float64
+	// range of the severity of catastrophes.
+	MinSeverity, MaxSeverity float64
+}
+
+// NewShock returns a shock, which is one of allele, motif, fitness and catastrophe,
+// or nil without a name.
+// Fitness shocks choose the survivors with the fitness map.
+func NewShock(params ShockParams, fitness FitnessMap, src rand.Source) (Shock, error) {
+	switch params.Name {
+	case "":
+		return nil, nil
+	case "allele":
+		if len(params.Allele) != 1 || params.Site < 0 {
+			return nil, fmt.Errorf("allele shock requires a site and an allele of one letter, but got %d and %q", params.Site, params.Allele)
+		}
+		return NewAlleleShock(params.Site, params.Allele[0], params.Efficacy, src), nil
+	case "motif":
+		if params.Motif == "" {
+			return nil, fmt.Errorf("motif shock requires a motif")
+		}
+		return NewMotifShock([]byte(params.Motif), params.Efficacy, src), nil
+	case "fitness":
+		if params.Factor < 0 || params.Factor > 1 {
+			return nil, fmt.Errorf("fitness shock requires a factor in [0, 1], but got %f", params.Factor)
+		}
+		if fitness == nil {
+			fitness = ExponentialMap{}
+		}
+		return NewFitnessShock(fitness, params.Factor, src), nil
+	case "catastrophe":
+		if params.MinSeverity < 0 || params.MaxSeverity > 1 || params.MinSeverity > params.MaxSeverity {
+			return nil, fmt.Errorf("catastrophe requires severities 0 <= min <= max <= 1, but got %f and %f", params.MinSeverity, params.MaxSeverity)
+		}
+		return NewCatastrophe(params.MinSeverity, params.MaxSeverity, src), nil
+	}
+	return nil, fmt.Errorf("unknown shock %s", params.Name)
+}
+
 // Recover recovers the population exponentially.
 func Recover(p *Pop, finalSize int) *Pop {
 	for p.Size() < finalSize {
@@ -56,6 +256,78 @@ func Recover(p *Pop, finalSize int) *Pop {
 		p.Genomes = append(p.Genomes, daughter)
 		p.Lineages = append(p.Lineages, nil)
 		p.Lineages[index], p.Lineages[p.Size()-1] = createNewLineages(p.Lineages[index], p.NumGeneration)
+		if p.Ancestries != nil {
+			a := p.Ancestries[index]
+			p.Ancestries[index] = a.inherit(p.NumGeneration)
+			p.Ancestries = append(p.Ancestries, a.inherit(p.NumGeneration))
+		}
 	}
 	return p
 }
+
+// Growth grows a population by divisions of genomes,
+// which are chosen with the weights given by Fitness at the start of the growth,
+// or uniformly if it is nil.
+// The daughters of a division keep the weight of their mother.
+type Growth struct {
+	Fitness FitnessMap
+
+	r *rand.Rand
+}
+
+// NewGrowth returns a new Growth.
+func NewGrowth(fitness FitnessMap, src rand.Source) *Growth {
+	return &Growth{Fitness: fitness, r: rand.New(src)}
+}
+
+// Grow grows the population to the final size,
+// calling the function after each division if it is not nil,
+// and stops at the first error of the function.
+func (g *Growth) Grow(p *Pop, finalSize int, divided func(p *Pop) error) (*Pop, error) {
+	if p.Size() == 0 {
+		return p, nil
+	}
+	if len(p.Lineages) < p.Size() {
+		p.NewLineages()
+	}
+
+	var weights []float64
+	if g.Fitness != nil {
+		weights = g.Fitness.Weights(p)
+	} else {
+		weights = make([]float64, p.Size())
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	// cumulative weights, to which the daughters are appended.
+	cum := make([]float64, len(weights))
+	total := 0.0
+	for i, w := range weights {
+		total += w
+		cum[i] = total
+	}
+
+	for p.Size() < finalSize {
+		var index int
+		if total > 0 {
+			v := g.r.Float64() * total
+			index = sort.Search(len(cum), func(i int) bool { return cum[i] > v })
+		} else {
+			index = g.r.Intn(p.Size())
+		}
+		w := weights[index]
+		weights = append(weights, w)
+		total += w
+		cum = append(cum, total)
+
+		p.NumGeneration++
+		p.birth(index)
+		if divided != nil {
+			if err := divided(p); err != nil {
+				return p, err
+			}
+		}
+	}
+	return p, nil
+}
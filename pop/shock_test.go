@@ -0,0 +1,181 @@
+package pop
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestGrowth(t *testing.T) {
+	src := rand.NewSource(1)
+	p := New()
+	NewRandomPopGenerator(rand.New(src), 10, 10, []byte("ACGT")).Operate(p)
+	p.EnableARG()
+	for i, g := range p.Genomes {
+		if i < 5 {
+			g.(FitnessUpdater).UpdateFitness(1, BeneficialMutation)
+		}
+	}
+
+	divisions := 0
+	if _, err := NewGrowth(LinearMap{}, src).Grow(p, 1000, func(*Pop) error { divisions++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if p.Size() != 1000 || len(p.Lineages) != 1000 || len(p.Ancestries) != 1000 {
+		t.Errorf("Expect a population of 1000 genomes\n")
+	}
+	if divisions != 990 || p.NumGeneration != 990 {
+		t.Errorf("Expect 990 divisions, but got %d\n", divisions)
+	}
+	// the fitter genomes divide twice as fast.
+	fitter := 0
+	for _, g := range p.Genomes {
+		if g.Fitness() == 1 {
+			fitter++
+		}
+	}
+	if fitter < 550 {
+		t.Errorf("Expect the fitter genomes to take over, but got %d of 1000\n", fitter)
+	}
+}
+
+func TestSelectiveShocks(t *testing.T) {
+	src := rand.NewSource(1)
+	p := New()
+	NewRandomPopGenerator(rand.New(src), 100, 10, []byte("C")).Operate(p)
+	p.EnableARG()
+	for i, g := range p.Genomes {
+		if i < 10 {
+			g.(FitnessUpdater).UpdateFitness(1, BeneficialMutation)
+		}
+		// half of the genomes carry the allele, and a third of them the motif.
+		if i%2 == 0 {
+			g.Seq()[0] = 'A'
+		}
+		if i%3 == 0 {
+			copy(g.Seq()[5:], "AAA")
+		}
+	}
+	// check that the survivors keep their lineages and ancestries.
+	check := func(name string, q *Pop) {
+		index := make(map[*Lineage]int)
+		for i, l := range p.Lineages {
+			index[l] = i
+		}
+		if len(q.Lineages) != q.Size() || len(q.Ancestries) != q.Size() {
+			t.Errorf("Expect the lineages and ancestries of the survivors of %s\n", name)
+		}
+		for k, l := range q.Lineages {
+			i, found := index[l]
+			if !found || q.Genomes[k] != p.Genomes[i] {
+				t.Errorf("Expect the lineage of the survivor %d of %s\n", k, name)
+			}
+		}
+	}
+
+	allele := NewAlleleShock(0, 'A', 1, src).Reduce(p)
+	check("allele shock", allele)
+	for _, g := range allele.Genomes {
+		if g.Seq()[0] != 'A' {
+			t.Errorf("Expect only resistant genomes to survive an allele shock\n")
+		}
+	}
+	if allele.Size() != 50 {
+		t.Errorf("Expect 50 resistant genomes to survive, but got %d\n", allele.Size())
+	}
+	if q := NewAlleleShock(0, 'A', 0, src).Reduce(p); q.Size() != p.Size() {
+		t.Errorf("Expect no deaths without efficacy\n")
+	}
+
+	motif := NewMotifShock([]byte("AAA"), 1, src).Reduce(p)
+	check("motif shock", motif)
+	for _, g := range motif.Genomes {
+		if !bytes.Contains(g.Seq(), []byte("AAA")) {
+			t.Errorf("Expect only genomes with the motif to survive a motif shock\n")
+		}
+	}
+	if motif.Size() != 34 {
+		t.Errorf("Expect 34 genomes with the motif to survive, but got %d\n", motif.Size())
+	}
+
+	fitness := NewFitnessShock(TruncationMap{Fraction: 0.1}, 0.05, src).Reduce(p)
+	check("fitness shock", fitness)
+	if fitness.Size() != 5 {
+		t.Errorf("Expect 5 survivors of a fitness shock, but got %d\n", fitness.Size())
+	}
+	for _, g := range fitness.Genomes {
+		if g.Fitness() != 1 {
+			t.Errorf("Expect only the fittest genomes to survive a fitness shock\n")
+		}
+	}
+
+	catastrophe := NewCatastrophe(0.5, 0.5, src).Reduce(p)
+	check("catastrophe", catastrophe)
+	if catastrophe.Size() < 30 || catastrophe.Size() > 70 {
+		t.Errorf("Expect about half of the genomes to survive a catastrophe, but got %d\n", catastrophe.Size())
+	}
+	if q := NewCatastrophe(1, 1, src).Reduce(p); q.Size() != 0 {
+		t.Errorf("Expect a full catastrophe to kill all genomes\n")
+	}
+	if p.Size() != 100 {
+		t.Errorf("Expect shocks to leave the population intact\n")
+	}
+
+	if _, err := NewShock(ShockParams{Name: "allele", Allele: "AC"}, nil, src); err == nil {
+		t.Errorf("Expect an error for an allele of two letters\n")
+	}
+	if s, err := NewShock(ShockParams{}, nil, src); s != nil || err != nil {
+		t.Errorf("Expect no shock without a name\n")
+	}
+}
+
+func TestShockMutations(t *testing.T) {
+	p := New()
+	NewRandomPopGenerator(rand.New(rand.NewSource(1)), 10, 10, []byte("ACGT")).Operate(p)
+	p.Mutations = make([]Mutation, 2, 10)
+	p.Mutations[0].Pos, p.Mutations[1].Pos = 1, 2
+	q := Dilution{Factor: 0.5}.Reduce(p)
+
+	// changes of the mutations of either population don't affect the other.
+	p.Mutations = append(p.Mutations[:1], Mutation{Pos: 3})
+	q.Mutations = append(q.Mutations, Mutation{Pos: 4})
+	if p.Mutations[1].Pos != 3 || q.Mutations[1].Pos != 2 || q.Mutations[2].Pos != 4 {
+		t.Errorf("Expect the survivors to own their mutations, but got %v and %v\n", p.Mutations, q.Mutations)
+	}
+}
+
+func TestDilutionSeed(t *testing.T) {
+	p := New()
+	NewRandomPopGenerator(rand.New(rand.NewSource(1)), 20, 10, []byte("ACGT")).Operate(p)
+	for i := range p.Genomes {
+		p.Genomes[i].(*NeutralGenome).Sequence[0] = byte('a' + i)
+	}
+
+	q1 := NewDilution(0.25, rand.NewSource(2)).Reduce(p)
+	q2 := NewDilution(0.25, rand.NewSource(2)).Reduce(p)
+	if q1.Size() != 5 || q2.Size() != 5 {
+		t.Fatalf("Expect 5 survivors, but got %d and %d\n", q1.Size(), q2.Size())
+	}
+	for i := range q1.Genomes {
+		if q1.Genomes[i] != q2.Genomes[i] {
+			t.Errorf("Expect the same survivors with the same seed, but got %s and %s\n", q1.Genomes[i].Seq(), q2.Genomes[i].Seq())
+		}
+	}
+}
+
+func TestAlleleShockIndel(t *testing.T) {
+	p := New()
+	NewRandomPopGenerator(rand.New(rand.NewSource(1)), 3, 8, []byte("C")).Operate(p)
+	for _, g := range p.Genomes {
+		g.Seq()[4] = 'A'
+	}
+	// the allele shifts by an insertion before it in the first genome,
+	// and is deleted in the second one.
+	p.Genomes[0].(*NeutralGenome).Insert(1, []byte("GG"))
+	p.Genomes[1].(*NeutralGenome).Delete(3, 2)
+
+	q := NewAlleleShock(4, 'A', 1, rand.NewSource(2)).Reduce(p)
+	if q.Size() != 2 || q.Genomes[0] != p.Genomes[0] || q.Genomes[1] != p.Genomes[2] {
+		t.Errorf("Expect the genomes with the allele at the site to survive, but got %d survivors\n", q.Size())
+	}
+}
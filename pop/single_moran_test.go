@@ -30,7 +30,7 @@ func runOnePop(popSize, genomeLen int, mutRate, traRate float64, frag, numGen in
 
 	mutationEvent := &Event{
 		Rate: mutRate,
-		Ops:  NewSimpleMutator(r, alphabet),
+		Ops:  NewSimpleMutator(alphabet, r),
 		Pop:  p,
 	}
 
@@ -42,6 +42,7 @@ func runOnePop(popSize, genomeLen int, mutRate, traRate float64, frag, numGen in
 	}
 
 	poisson := random.NewPoisson(float64(genomeLen)*(mutRate+traRate), src)
+	rw := NewRouletteWheel(src)
 
 	eventChan := make(chan *Event)
 
@@ -54,7 +55,7 @@ func runOnePop(popSize, genomeLen int, mutRate, traRate float64, frag, numGen in
 				eventChan <- Emit([]*Event{
 					mutationEvent,
 					transferEvent,
-				}, r)
+				}, rw)
 			}
 		}
 	}()
@@ -83,7 +84,8 @@ func TestSingleMoran(t *testing.T) {
 			vard := desc.NewVarianceWithBiasCorrection()
 			for j := 0; j < replicates; j++ {
 				p := runOnePop(popSize, genomeLen, mutRate, traRate, frag, numGen)
-				d, _ := CalcKs(10, p)
+				src := rand.NewSource(time.Now().UnixNano())
+				d, _ := CalcKs(10, src, p)
 				mean.Increment(d)
 				vard.Increment(d)
 			}
@@ -0,0 +1,168 @@
+package pop
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// SiteRates stores the relative mutation rates of the sites of a genome,
+// which are fixed during a simulation.
+type SiteRates struct {
+	Rates []float64
+
+	cum []float64 // cumulative rates.
+}
+
+// NewSiteRates returns a SiteRates of the given rates.
+func NewSiteRates(rates []float64) *SiteRates {
+	s := SiteRates{Rates: rates}
+	total := 0.0
+	for _, r := range rates {
+		total += r
+		s.cum = append(s.cum, total)
+	}
+	return &s
+}
+
+// NewGammaSiteRates draws the rates of the sites from a discrete gamma distribution
+// of the shape, with the number of categories of equal probabilities,
+// as in Yang (1994), and sets a proportion of sites invariant.
+// The rates have mean one in expectation.
+// A shape of zero means no variation among the variable sites.
+func NewGammaSiteRates(length int, shape float64, categories int, invariant float64, src rand.Source) (*SiteRates, error) {
+	if shape < 0 {
+		return nil, fmt.Errorf("gamma shape must be non-negative, but got %f", shape)
+	}
+	if invariant < 0 || invariant >= 1 {
+		return nil, fmt.Errorf("proportion of invariant sites must be in [0, 1), but got %f", invariant)
+	}
+
+	categoryRates := []float64{1}
+	if shape > 0 {
+		if categories < 1 {
+			return nil, fmt.Errorf("number of gamma categories must be positive, but got %d", categories)
+		}
+		categoryRates = DiscreteGamma(shape, categories)
+	}
+
+	r := rand.New(src)
+	rates := make([]float64, length)
+	for i := range rates {
+		if r.Float64() < invariant {
+			continue
+		}
+		rates[i] = categoryRates[r.Intn(len(categoryRates))] / (1 - invariant)
+	}
+	return NewSiteRates(rates), nil
+}
+
+// Mean returns the mean rate of the sites.
+func (s *SiteRates) Mean() float64 {
+	if len(s.cum) == 0 {
+		return 0
+	}
+	return s.cum[len(s.cum)-1] / float64(len(s.cum))
+}
+
+// Sample returns a site chosen according to the rates.
+func (s *SiteRates) Sample(r Rand) int {
+	v := r.Float64() * s.cum[len(s.cum)-1]
+	return sort.Search(len(s.cum), func(i int) bool { return s.cum[i] > v })
+}
+
+// samplePosition returns a position of the genome chosen according to the site rates,
+// or uniformly if they are nil.
+// The site rates are in the ancestral coordinates,
+// so that it returns -1 if the chosen site has been deleted.
+func samplePosition(sites *SiteRates, r Rand, g Genome) int {
+	if sites == nil {
+		return r.Intn(g.Length())
+	}
+	return genomePosition(g, sites.Sample(r))
+}
+
+// DiscreteGamma returns the mean rates of the categories of equal probabilities
+// of a gamma distribution with the shape and mean one.
+func DiscreteGamma(shape float64, categories int) []float64 {
+	rates := make([]float64, categories)
+	// the mean of the gamma distribution over [a, b)
+	// is given by the gamma distribution of shape + 1.
+	prev := 0.0
+	for k := 0; k < categories; k++ {
+		next := 1.0
+		if k < categories-1 {
+			b := gammaQuantile(shape, float64(k+1)/float64(categories))
+			next = regularizedGammaP(shape+1, shape*b)
+		}
+		rates[k] = (next - prev) * float64(categories)
+		prev = next
+	}
+	return rates
+}
+
+// gammaQuantile returns the quantile of the gamma distribution
+// with the shape and mean one, by bisection.
+func gammaQuantile(shape, p float64) float64 {
+	lo, hi := 0.0, 1.0
+	for regularizedGammaP(shape, shape*hi) < p {
+		lo = hi
+		hi *= 2
+	}
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if regularizedGammaP(shape, shape*mid) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// regularizedGammaP returns the regularized lower incomplete gamma function P(a, x),
+// using its series for x < a + 1, and its continued fraction otherwise.
+func regularizedGammaP(a, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	lg, _ := math.Lgamma(a)
+	if x < a+1 {
+		sum, term := 1/a, 1/a
+		for n := 1; n < 1000; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return sum * math.Exp(-x+a*math.Log(x)-lg)
+	}
+
+	// modified Lentz's method.
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < 1000; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return 1 - math.Exp(-x+a*math.Log(x)-lg)*h
+}
@@ -0,0 +1,58 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDiscreteGamma(t *testing.T) {
+	// values from Yang (1994).
+	expected := map[float64][]float64{
+		0.5: []float64{0.0334, 0.2519, 0.8203, 2.8944},
+		1.0: []float64{0.1369, 0.4767, 1.0000, 2.3863},
+	}
+	for shape, rates := range expected {
+		for i, v := range DiscreteGamma(shape, 4) {
+			if math.Abs(v-rates[i]) > 1e-4 {
+				t.Errorf("Expect rate %f of category %d with shape %f, but got %f\n", rates[i], i, shape, v)
+			}
+		}
+	}
+}
+
+func TestSiteRates(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	sites := NewSiteRates([]float64{0, 1, 0, 3})
+	counts := make([]float64, 4)
+	n := 40000
+	for i := 0; i < n; i++ {
+		counts[sites.Sample(r)]++
+	}
+	for i, v := range []float64{0, 0.25, 0, 0.75} {
+		if math.Abs(counts[i]/float64(n)-v) > 0.01 {
+			t.Errorf("Expect frequency %f of site %d, but got %f\n", v, i, counts[i]/float64(n))
+		}
+	}
+
+	gamma, err := NewGammaSiteRates(100000, 0.5, 4, 0.2, rand.NewSource(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invariant := 0
+	for _, v := range gamma.Rates {
+		if v == 0 {
+			invariant++
+		}
+	}
+	if v := float64(invariant) / 100000; math.Abs(v-0.2) > 0.01 {
+		t.Errorf("Expect proportion of invariant sites %f, but got %f\n", 0.2, v)
+	}
+	if v := gamma.Mean(); math.Abs(v-1) > 0.02 {
+		t.Errorf("Expect mean rate 1, but got %f\n", v)
+	}
+
+	if _, err := NewGammaSiteRates(10, 1, 4, 1, rand.NewSource(1)); err == nil {
+		t.Errorf("Expect error for all sites invariant\n")
+	}
+}
@@ -0,0 +1,191 @@
+package pop
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/mingzhi/numgo/random"
+)
+
+// SubstitutionModel is a time-reversible nucleotide substitution model,
+// given by the equilibrium frequencies of the bases
+// and the rate matrix of substitutions between them.
+//
+// The alphabet is read in the order of A, C, G and T,
+// so that A <-> G and C <-> T are transitions.
+// The rates are normalized to one substitution per site at equilibrium.
+type SubstitutionModel struct {
+	Alphabet []byte
+	Freqs    []float64
+	Rates    [][]float64 // Rates[i][j] is the rate from base i to j.
+}
+
+// NewGTR returns a general time-reversible model,
+// given the exchangeabilities between A-C, A-G, A-T, C-G, C-T and G-T,
+// and the equilibrium frequencies of A, C, G and T.
+func NewGTR(alphabet []byte, exchanges, freqs []float64) (*SubstitutionModel, error) {
+	if len(alphabet) != 4 {
+		return nil, fmt.Errorf("substitution model requires 4 bases, but got %d", len(alphabet))
+	}
+	if len(exchanges) != 6 {
+		return nil, fmt.Errorf("GTR requires 6 exchangeabilities, but got %d", len(exchanges))
+	}
+	if len(freqs) != 4 {
+		return nil, fmt.Errorf("substitution model requires 4 frequencies, but got %d", len(freqs))
+	}
+	total := 0.0
+	for _, f := range freqs {
+		if f <= 0 {
+			return nil, fmt.Errorf("base frequencies must be positive, but got %v", freqs)
+		}
+		total += f
+	}
+	if math.Abs(total-1) > 1e-6 {
+		return nil, fmt.Errorf("base frequencies must sum to 1, but got %v", freqs)
+	}
+	for _, e := range exchanges {
+		if e <= 0 {
+			return nil, fmt.Errorf("exchangeabilities must be positive, but got %v", exchanges)
+		}
+	}
+
+	m := SubstitutionModel{Alphabet: alphabet, Freqs: freqs}
+	m.Rates = make([][]float64, 4)
+	for i := range m.Rates {
+		m.Rates[i] = make([]float64, 4)
+	}
+	k := 0
+	for i := 0; i < 4; i++ {
+		for j := i + 1; j < 4; j++ {
+			m.Rates[i][j] = exchanges[k] * freqs[j]
+			m.Rates[j][i] = exchanges[k] * freqs[i]
+			k++
+		}
+	}
+
+	// normalize the mean rate at equilibrium.
+	mean := 0.0
+	for i := 0; i < 4; i++ {
+		mean += freqs[i] * m.Rate(i)
+	}
+	for i := range m.Rates {
+		for j := range m.Rates[i] {
+			m.Rates[i][j] /= mean
+		}
+	}
+
+	return &m, nil
+}
+
+// NewHKY85 returns a HKY85 model, given the transition/transversion rate ratio
+// and the equilibrium frequencies of A, C, G and T.
+func NewHKY85(alphabet []byte, kappa float64, freqs []float64) (*SubstitutionModel, error) {
+	return NewGTR(alphabet, []float64{1, kappa, 1, 1, kappa, 1}, freqs)
+}
+
+// NewK80 returns a K80 model, given the transition/transversion rate ratio.
+func NewK80(alphabet []byte, kappa float64) (*SubstitutionModel, error) {
+	return NewHKY85(alphabet, kappa, []float64{0.25, 0.25, 0.25, 0.25})
+}
+
+// NewJC69 returns a JC69 model, with equal rates between bases.
+func NewJC69(alphabet []byte) (*SubstitutionModel, error) {
+	return NewK80(alphabet, 1)
+}
+
+// NewSubstitutionModel returns a substitution model by its name,
+// which is one of JC69, K80, HKY85 and GTR.
+// The parameters not used by the model are ignored.
+func NewSubstitutionModel(name string, alphabet []byte, kappa float64, exchanges, freqs []float64) (*SubstitutionModel, error) {
+	switch name {
+	case "JC69":
+		return NewJC69(alphabet)
+	case "K80":
+		return NewK80(alphabet, kappa)
+	case "HKY85":
+		return NewHKY85(alphabet, kappa, freqs)
+	case "GTR":
+		return NewGTR(alphabet, exchanges, freqs)
+	}
+	return nil, fmt.Errorf("unknown substitution model %s", name)
+}
+
+// Rate returns the total rate of substitutions from the base i.
+func (m *SubstitutionModel) Rate(i int) float64 {
+	rate := 0.0
+	for j, r := range m.Rates[i] {
+		if j != i {
+			rate += r
+		}
+	}
+	return rate
+}
+
+// MaxRate returns the maximum of the total rates of the bases.
+func (m *SubstitutionModel) MaxRate() float64 {
+	max := 0.0
+	for i := range m.Rates {
+		if r := m.Rate(i); r > max {
+			max = r
+		}
+	}
+	return max
+}
+
+// ModelMutator mutates genomes under a nucleotide substitution model.
+//
+// Each operation chooses a site uniformly, and substitutes its base i
+// with the probability Rate(i) / MaxRate(),
+// so that the operation rate has to be scaled by MaxRate.
+type ModelMutator struct {
+	Model *SubstitutionModel
+	// Sites stores the relative rates of the sites,
+	// which are equal if it is nil.
+	Sites *SiteRates
+
+	r *random.Rand
+}
+
+// NewModelMutator returns a new ModelMutator.
+func NewModelMutator(model *SubstitutionModel, src rand.Source) *ModelMutator {
+	return &ModelMutator{Model: model, r: random.New(src)}
+}
+
+// Operate mutates a single position at a genome from the *Pop.
+func (m *ModelMutator) Operate(p *Pop) {
+	g := m.r.Intn(p.Size())
+	pos := samplePosition(m.Sites, m.r, p.Genomes[g])
+	if pos < 0 {
+		return
+	}
+
+	seq := p.Genomes[g].Seq()
+	i := bytes.IndexByte(m.Model.Alphabet, seq[pos])
+	if i < 0 {
+		return
+	}
+	rate := m.Model.Rate(i)
+	if m.r.Float64()*m.Model.MaxRate() >= rate {
+		return
+	}
+
+	// choose the new base according to the rates.
+	v := m.r.Float64() * rate
+	j := -1
+	for k, r := range m.Model.Rates[i] {
+		if k == i {
+			continue
+		}
+		j = k
+		v -= r
+		if v < 0 {
+			break
+		}
+	}
+	setBase(p.Genomes[g], pos, m.Model.Alphabet[j])
+	if p.RecordMutations {
+		p.recordMutation(g, pos)
+	}
+}
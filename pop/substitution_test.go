@@ -0,0 +1,73 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSubstitutionModel(t *testing.T) {
+	alphabet := []byte("ACGT")
+	freqs := []float64{0.1, 0.2, 0.3, 0.4}
+	m, err := NewGTR(alphabet, []float64{1, 2, 3, 4, 5, 6}, freqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mean := 0.0
+	for i := 0; i < 4; i++ {
+		mean += freqs[i] * m.Rate(i)
+		for j := 0; j < 4; j++ {
+			if math.Abs(freqs[i]*m.Rates[i][j]-freqs[j]*m.Rates[j][i]) > 1e-10 {
+				t.Errorf("Expect detailed balance between %d and %d\n", i, j)
+			}
+		}
+	}
+	if math.Abs(mean-1) > 1e-10 {
+		t.Errorf("Expect normalized mean rate 1, but got %f\n", mean)
+	}
+
+	m, _ = NewK80(alphabet, 4)
+	if v := m.Rates[0][2] / m.Rates[0][1]; math.Abs(v-4) > 1e-10 {
+		t.Errorf("Expect transition/transversion ratio %f, but got %f\n", 4.0, v)
+	}
+
+	if _, err := NewHKY85(alphabet, 2, []float64{0.5, 0.5, 0.5, 0.5}); err == nil {
+		t.Errorf("Expect error for frequencies not summing to 1\n")
+	}
+	if _, err := NewSubstitutionModel("F81", alphabet, 0, nil, nil); err == nil {
+		t.Errorf("Expect error for unknown model\n")
+	}
+}
+
+func TestModelMutator(t *testing.T) {
+	alphabet := []byte("ACGT")
+	freqs := []float64{0.2, 0.3, 0.3, 0.2}
+	model, err := NewHKY85(alphabet, 3, freqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := rand.NewSource(1)
+	p := New()
+	NewRandomPopGenerator(rand.New(src), 1, 2000, alphabet).Operate(p)
+	mutator := NewModelMutator(model, src)
+	for i := 0; i < 100000; i++ {
+		mutator.Operate(p)
+	}
+
+	// the composition converges to the equilibrium frequencies.
+	counts := make([]float64, 4)
+	for _, b := range p.Genomes[0].Seq() {
+		for i, a := range alphabet {
+			if a == b {
+				counts[i]++
+			}
+		}
+	}
+	for i := range counts {
+		if v := counts[i] / float64(p.Length()); math.Abs(v-freqs[i]) > 0.04 {
+			t.Errorf("Expect frequency %f of %c, but got %f\n", freqs[i], alphabet[i], v)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package pop
 
 import (
+	"math"
 	"math/rand"
 
 	"github.com/mingzhi/numgo/random"
@@ -47,8 +48,19 @@ type FragSizeGenerator interface {
 // one to be the donor, and the other to be the receiver.
 // And a piece of the receiver's genome will be replaced by
 // a sequence at corresponding genomic positions.
+//
+// If Delta is positive, a transfer is accepted with the probability exp(-d/Delta),
+// where d is the divergence between the donor and the receiver
+// over the fragment, or over the flanking regions of Seed positions
+// on both sides of the fragment if Seed is positive.
+//
+// The start point is chosen according to the recombination map,
+// or uniformly if Map is nil.
 type SimpleTransfer struct {
-	Frag FragSizeGenerator
+	Frag  FragSizeGenerator
+	Delta float64
+	Seed  int
+	Map   *RecombinationMap
 
 	r *random.Rand
 }
@@ -62,22 +74,116 @@ func (s *SimpleTransfer) Operate(p *Pop) {
 	// We first randomly decise two sequences.
 	a := s.r.Intn(p.Size())
 	b := s.r.Intn(p.Size())
-	length = p.Genomes[a].Length()
+	// positions are in the ancestral coordinates,
+	// in case of insertions and deletions.
+	length = p.RefLength()
 	if a != b {
 		// Randomly determine the start point of the transfer
-		start := s.r.Intn(length)
-		end := start + s.Frag.Size()
-		// We need to check whether the end point hits the end of the sequence.
-		// And whether is a circled sequence or not.
-		if end < length {
-			copy(p.Genomes[a].Seq()[start:end], p.Genomes[b].Seq()[start:end])
-		} else {
-			copy(p.Genomes[a].Seq()[start:length], p.Genomes[b].Seq()[start:length])
-			if p.Circled {
-				copy(p.Genomes[a].Seq()[0:end-length], p.Genomes[b].Seq()[0:end-length])
+		start := s.start(length)
+		end := start + s.fragSize(length)
+		p.Transfer.Attempted++
+		if !s.accept(p.Genomes[a], p.Genomes[b], start, end, length, p.Circled) {
+			return
+		}
+		p.Transfer.Accepted++
+		copyStretch(p.Genomes[a], p.Genomes[b], start, end, length, p.Circled)
+		recordTransfer(p, a, p, b, start, end, length)
+	}
+}
+
+// start returns the start point of a transfer.
+func (s *SimpleTransfer) start(length int) int {
+	if s.Map != nil {
+		return s.Map.Sample(s.r)
+	}
+	return s.r.Intn(length)
+}
+
+// fragSize returns the size of a fragment,
+// which is at most the genome length.
+func (s *SimpleTransfer) fragSize(length int) int {
+	size := s.Frag.Size()
+	if size > length {
+		return length
+	}
+	if size < 0 {
+		return 0
+	}
+	return size
+}
+
+// accept determines whether the transfer of the stretch [start, end)
+// from the donor to the recipient is accepted.
+func (s *SimpleTransfer) accept(recipient, donor Genome, start, end, length int, circled bool) bool {
+	if s.Delta <= 0 {
+		return true
+	}
+	var d float64
+	if s.Seed > 0 {
+		d = divergence(recipient, donor, length, circled, [2]int{start - s.Seed, start}, [2]int{end, end + s.Seed})
+	} else {
+		d = divergence(recipient, donor, length, circled, [2]int{start, end})
+	}
+	return s.r.Float64() < math.Exp(-d/s.Delta)
+}
+
+// divergence returns the proportion of different sites between two genomes
+// in the stretches of the ancestral coordinates,
+// which wrap around the ends of a circled genome,
+// and are truncated at the ends otherwise.
+// A site deleted from one genome is counted as different.
+func divergence(a, b Genome, length int, circled bool, stretches ...[2]int) float64 {
+	n, diffs := 0, 0
+	for _, s := range stretches {
+		for c := s[0]; c < s[1]; c++ {
+			coord := c
+			if circled {
+				coord = ((c % length) + length) % length
+			} else if c < 0 || c >= length {
+				continue
+			}
+			n++
+			i, j := genomePosition(a, coord), genomePosition(b, coord)
+			if i < 0 && j < 0 {
+				continue
+			}
+			if i < 0 || j < 0 || a.Seq()[i] != b.Seq()[j] {
+				diffs++
 			}
 		}
 	}
+	if n == 0 {
+		return 0
+	}
+	return float64(diffs) / float64(n)
+}
+
+// TransferStats counts the transfers into a population.
+type TransferStats struct {
+	Attempted int
+	Accepted  int
+}
+
+// AcceptanceRate returns the proportion of accepted transfers.
+func (t TransferStats) AcceptanceRate() float64 {
+	if t.Attempted == 0 {
+		return 0
+	}
+	return float64(t.Accepted) / float64(t.Attempted)
+}
+
+// copyStretch copies the ancestral stretch [start, end) from the donor to the recipient.
+// We need to check whether the end point hits the end of the sequence,
+// and whether it is a circled sequence or not.
+func copyStretch(recipient, donor Genome, start, end, length int, circled bool) {
+	if end < length {
+		copyHomologous(recipient, donor, start, end)
+	} else {
+		copyHomologous(recipient, donor, start, length)
+		if circled {
+			copyHomologous(recipient, donor, 0, minInt(end-length, start))
+		}
+	}
 }
 
 // OutTransfer implements transfers from a donor population to a receiver one.
@@ -106,18 +212,16 @@ func (o *OutTransfer) Operate(p *Pop) {
 	a := o.r.Intn(o.DonorPop.Size())
 	b := o.r.Intn(p.Size())
 
-	length = p.Genomes[b].Length()
+	length = p.RefLength()
 
 	// Randomly determine the start point of the transfer.
-	start := o.r.Intn(length)
-	end := start + o.Frag.Size()
-	// We need to check whether the point hits the boundary of the sequence.
-	if end < length {
-		copy(p.Genomes[b].Seq()[start:end], o.DonorPop.Genomes[a].Seq()[start:end])
-	} else {
-		copy(p.Genomes[b].Seq()[start:length], o.DonorPop.Genomes[a].Seq()[start:length])
-		if p.Circled {
-			copy(p.Genomes[b].Seq()[0:end-length], o.DonorPop.Genomes[a].Seq()[0:end-length])
-		}
+	start := o.start(length)
+	end := start + o.fragSize(length)
+	p.Transfer.Attempted++
+	if !o.accept(p.Genomes[b], o.DonorPop.Genomes[a], start, end, length, p.Circled) {
+		return
 	}
+	p.Transfer.Accepted++
+	copyStretch(p.Genomes[b], o.DonorPop.Genomes[a], start, end, length, p.Circled)
+	recordTransfer(p, b, o.DonorPop, a, start, end, length)
 }
@@ -0,0 +1,89 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDivergence(t *testing.T) {
+	a := &NeutralGenome{Sequence: ByteSequence("AAAAAAAAAA")}
+	b := &NeutralGenome{Sequence: ByteSequence("CAAAAAAAAC")}
+	if d := divergence(a, b, 10, false, [2]int{0, 5}); d != 0.2 {
+		t.Errorf("Expect divergence 0.2, but got %f\n", d)
+	}
+	// flanking regions wrap around a circled genome.
+	if d := divergence(a, b, 10, true, [2]int{-2, 0}, [2]int{5, 7}); d != 0.25 {
+		t.Errorf("Expect divergence 0.25, but got %f\n", d)
+	}
+	if d := divergence(a, b, 10, false, [2]int{-2, 0}, [2]int{5, 7}); d != 0 {
+		t.Errorf("Expect divergence 0, but got %f\n", d)
+	}
+}
+
+func TestTransferAcceptance(t *testing.T) {
+	for _, delta := range []float64{0, 0.1, 0.5} {
+		p := New()
+		p.Genomes = []Genome{
+			&NeutralGenome{Sequence: ByteSequence("AAAAAAAAAAAAAAAAAAAA")},
+			&NeutralGenome{Sequence: ByteSequence("AAAAAAAAAACCCCCCCCCC")},
+		}
+		// the fragment covers the whole circled genome.
+		p.Circled = true
+		transfer := NewSimpleTransfer(NewConstantFrag(20), rand.NewSource(1))
+		transfer.Delta = delta
+		for i := 0; i < 10000; i++ {
+			transfer.Operate(p)
+			p.Genomes[0].(*NeutralGenome).Sequence = ByteSequence("AAAAAAAAAAAAAAAAAAAA")
+			p.Genomes[1].(*NeutralGenome).Sequence = ByteSequence("AAAAAAAAAACCCCCCCCCC")
+		}
+
+		expected := 1.0
+		if delta > 0 {
+			expected = math.Exp(-0.5 / delta)
+		}
+		if v := p.Transfer.AcceptanceRate(); math.Abs(v-expected) > 0.02 {
+			t.Errorf("Expect acceptance rate %f with delta %f, but got %f\n", expected, delta, v)
+		}
+	}
+}
+
+func TestLongFragments(t *testing.T) {
+	src := rand.NewSource(1)
+	for _, circled := range []bool{true, false} {
+		p := New()
+		NewRandomPopGenerator(rand.New(src), 10, 10, []byte("A")).Operate(p)
+		p.Circled = circled
+		p.EnableARG()
+		donor := New()
+		NewRandomPopGenerator(rand.New(src), 10, 10, []byte("C")).Operate(donor)
+		donor.Circled = circled
+
+		// fragments are longer than the genomes.
+		frag, err := NewFragSizeGenerator("uniform", 0, FragParams{Min: 11, Max: 30}, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := NewSimpleTransfer(frag, src)
+		out := NewOutTransfer(frag, donor, src)
+		for i := 0; i < 100; i++ {
+			in.Operate(p)
+			out.Operate(p)
+		}
+		for i, g := range p.Genomes {
+			seq := string(g.Seq())
+			// a fragment covers the rest of the genome from its start,
+			// and the whole of a circled genome.
+			if circled && seq != "AAAAAAAAAA" && seq != "CCCCCCCCCC" {
+				t.Errorf("Expect a whole genome transferred, but got %s\n", seq)
+			}
+			if len(seq) != 10 {
+				t.Errorf("Expect genomes of length 10, but got %d\n", len(seq))
+			}
+			a := p.Ancestries[i]
+			if a[0].Start != 0 || a[len(a)-1].End != 10 {
+				t.Errorf("Expect the ancestry to cover the genome, but got %v\n", a)
+			}
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package pop
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Mutation is a mutation carried by a lineage,
+// which changed the state of a position at a time.
+type Mutation struct {
+	Lineage *Lineage
+	Pos     int
+	State   byte
+	Time    int
+}
+
+// recordMutation records the current state at the position of the genome g,
+// on the lineage of the genome at the position.
+// Positions are recorded in the ancestral coordinates,
+// and mutations at inserted positions are not recorded.
+func (p *Pop) recordMutation(g, pos int) {
+	coord := genomeCoord(p.Genomes[g], pos)
+	if coord < 0 {
+		return
+	}
+	var l *Lineage
+	if p.Ancestries != nil {
+		l = p.Ancestries[g].At(coord)
+	} else if g < len(p.Lineages) {
+		l = p.Lineages[g]
+	}
+	if l == nil {
+		return
+	}
+	m := Mutation{Lineage: l, Pos: coord, State: p.Genomes[g].Seq()[pos], Time: p.NumGeneration}
+	p.Mutations = append(p.Mutations, m)
+}
+
+// TreeSequence stores the genealogy of a population
+// in the node, edge, site and mutation tables of tskit.
+//
+// Times are in generations before the present.
+type TreeSequence struct {
+	SequenceLength int
+	Nodes          []TableNode
+	Edges          []TableEdge
+	Sites          []TableSite
+	Mutations      []TableMutation
+}
+
+// TableNode is a row of the node table.
+type TableNode struct {
+	IsSample bool
+	Time     float64
+}
+
+// TableEdge is a row of the edge table,
+// in which the child inherits the stretch [Left, Right) from the parent.
+type TableEdge struct {
+	Left, Right   int
+	Parent, Child int
+}
+
+// TableSite is a row of the site table.
+type TableSite struct {
+	Position       int
+	AncestralState byte
+}
+
+// TableMutation is a row of the mutation table.
+// Parent is the index of the previous mutation at the site
+// inherited by the node, or -1.
+type TableMutation struct {
+	Site         int
+	Node         int
+	DerivedState byte
+	Parent       int
+	Time         float64
+}
+
+// interval is a stretch [start, end) of a genome.
+type interval struct {
+	start, end int
+}
+
+// unionIntervals returns the union of two sorted lists of disjoint intervals.
+func unionIntervals(a, b []interval) []interval {
+	all := append(append([]interval{}, a...), b...)
+	sort.Slice(all, func(i, j int) bool { return all[i].start < all[j].start })
+	res := []interval{}
+	for _, v := range all {
+		if n := len(res); n > 0 && v.start <= res[n-1].end {
+			res[n-1].end = maxInt(res[n-1].end, v.end)
+			continue
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// contains returns whether the position is in the sorted intervals.
+func contains(intervals []interval, pos int) bool {
+	i := sort.Search(len(intervals), func(i int) bool { return intervals[i].end > pos })
+	return i < len(intervals) && intervals[i].start <= pos
+}
+
+// NewTreeSequence returns the tree sequence of the population.
+//
+// Each genome is a sample node at time zero,
+// and each lineage with sampled descendants is an ancestral node
+// born at NumGeneration - BirthTime + 1 generations ago.
+// Lineages born in the same generation as their parents
+// are moved by a fraction of generation, so that parents are strictly older.
+// Without the ARG mode, each genome inherits the whole genome from its lineage.
+//
+// Mutations recorded on the lineages are placed above their nodes,
+// no younger than the birth of the lineages.
+// Unary nodes are kept; pruning the lineages beforehand removes most of them.
+func NewTreeSequence(p *Pop) *TreeSequence {
+	length := p.RefLength()
+	leafTime := p.NumGeneration + 1
+	ts := &TreeSequence{SequenceLength: length}
+
+	ancestries := p.Ancestries
+	if ancestries == nil {
+		ancestries = make([]Ancestry, p.Size())
+		for i := range ancestries {
+			var l *Lineage
+			if i < len(p.Lineages) {
+				l = p.Lineages[i]
+			}
+			ancestries[i] = NewAncestry(length, l)
+		}
+	}
+
+	// number of daughters of each lineage, which are not processed yet.
+	pending := make(map[*Lineage]int)
+	seen := make(map[*Lineage]bool)
+	visited := Lineages{}
+	for _, a := range ancestries {
+		for _, s := range a {
+			for l := s.Lineage; l != nil && !seen[l]; l = l.Parent {
+				seen[l] = true
+				visited = append(visited, l)
+				if l.Parent != nil {
+					pending[l.Parent]++
+				}
+			}
+		}
+	}
+	eps := 1.0 / float64(len(visited)+1)
+
+	// sample nodes and their edges,
+	// where the parents are resolved after numbering the lineages.
+	intervals := make(map[*Lineage][]interval)
+	minTimes := make(map[*Lineage]float64)
+	type edge struct {
+		left, right int
+		parent      *Lineage
+		child       int
+	}
+	edges := []edge{}
+	for i, a := range ancestries {
+		ts.Nodes = append(ts.Nodes, TableNode{IsSample: true, Time: 0})
+		for _, s := range a {
+			if s.Lineage == nil {
+				continue
+			}
+			intervals[s.Lineage] = unionIntervals(intervals[s.Lineage], []interval{{s.Start, s.End}})
+			edges = append(edges, edge{left: s.Start, right: s.End, parent: s.Lineage, child: i})
+			minTimes[s.Lineage] = eps
+		}
+	}
+
+	queue := Lineages{}
+	for _, l := range visited {
+		if pending[l] == 0 {
+			queue = append(queue, l)
+		}
+	}
+	sort.SliceStable(queue, func(i, j int) bool { return queue[i].BirthTime > queue[j].BirthTime })
+
+	// process the lineages from the youngest ones,
+	// passing the inherited intervals to their parents.
+	ids := make(map[*Lineage]int)
+	times := make(map[*Lineage]float64)
+	for len(queue) > 0 {
+		l := queue[0]
+		queue = queue[1:]
+		t := float64(leafTime - l.BirthTime)
+		if t < minTimes[l] {
+			t = minTimes[l]
+		}
+		ids[l] = len(ts.Nodes)
+		times[l] = t
+		ts.Nodes = append(ts.Nodes, TableNode{IsSample: false, Time: t})
+
+		a := l.Parent
+		if a == nil {
+			continue
+		}
+		for _, v := range intervals[l] {
+			edges = append(edges, edge{left: v.start, right: v.end, parent: a, child: ids[l]})
+		}
+		intervals[a] = unionIntervals(intervals[a], intervals[l])
+		if t+eps > minTimes[a] {
+			minTimes[a] = t + eps
+		}
+		pending[a]--
+		if pending[a] == 0 {
+			queue = append(queue, a)
+		}
+	}
+
+	for _, e := range edges {
+		ts.Edges = append(ts.Edges, TableEdge{Left: e.left, Right: e.right, Parent: ids[e.parent], Child: e.child})
+	}
+	sort.SliceStable(ts.Edges, func(i, j int) bool {
+		a, b := ts.Edges[i], ts.Edges[j]
+		if ta, tb := ts.Nodes[a.Parent].Time, ts.Nodes[b.Parent].Time; ta != tb {
+			return ta < tb
+		}
+		if a.Parent != b.Parent {
+			return a.Parent < b.Parent
+		}
+		if a.Child != b.Child {
+			return a.Child < b.Child
+		}
+		return a.Left < b.Left
+	})
+
+	ts.addMutations(p, leafTime, intervals, ids, times)
+
+	return ts
+}
+
+// addMutations adds the sites and the mutations inherited by the samples.
+func (ts *TreeSequence) addMutations(p *Pop, leafTime int, intervals map[*Lineage][]interval, ids map[*Lineage]int, times map[*Lineage]float64) {
+	mutations := []Mutation{}
+	for _, m := range p.Mutations {
+		if _, found := ids[m.Lineage]; found && contains(intervals[m.Lineage], m.Pos) {
+			mutations = append(mutations, m)
+		}
+	}
+	sort.SliceStable(mutations, func(i, j int) bool {
+		if mutations[i].Pos != mutations[j].Pos {
+			return mutations[i].Pos < mutations[j].Pos
+		}
+		return mutations[i].Time < mutations[j].Time
+	})
+
+	// the latest mutation at the current site on each lineage.
+	var latest map[*Lineage]int
+	for _, m := range mutations {
+		if len(ts.Sites) == 0 || ts.Sites[len(ts.Sites)-1].Position != m.Pos {
+			var state byte
+			if m.Pos < len(p.Ancestor) {
+				state = p.Ancestor[m.Pos]
+			}
+			ts.Sites = append(ts.Sites, TableSite{Position: m.Pos, AncestralState: state})
+			latest = make(map[*Lineage]int)
+		}
+
+		parent := -1
+		for l := m.Lineage; l != nil; l = l.Parent {
+			if k, found := latest[l]; found {
+				parent = k
+				break
+			}
+		}
+
+		t := float64(leafTime - m.Time)
+		if t < times[m.Lineage] {
+			t = times[m.Lineage]
+		}
+		if a := m.Lineage.Parent; a != nil && t >= times[a] {
+			t = (times[m.Lineage] + times[a]) / 2
+		}
+
+		latest[m.Lineage] = len(ts.Mutations)
+		ts.Mutations = append(ts.Mutations, TableMutation{
+			Site:         len(ts.Sites) - 1,
+			Node:         ids[m.Lineage],
+			DerivedState: m.State,
+			Parent:       parent,
+			Time:         t,
+		})
+	}
+}
+
+// stateString returns a state as a printable character,
+// or as its number if it is not printable.
+func stateString(b byte) string {
+	if b > ' ' && b <= '~' {
+		return string([]byte{b})
+	}
+	return strconv.Itoa(int(b))
+}
+
+// WriteText writes the tables in the text format read by tskit.load_text.
+func (ts *TreeSequence) WriteText(nodes, edges, sites, mutations io.Writer) error {
+	if _, err := fmt.Fprintln(nodes, "id\tis_sample\ttime"); err != nil {
+		return err
+	}
+	for i, n := range ts.Nodes {
+		isSample := 0
+		if n.IsSample {
+			isSample = 1
+		}
+		if _, err := fmt.Fprintf(nodes, "%d\t%d\t%g\n", i, isSample, n.Time); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(edges, "left\tright\tparent\tchild"); err != nil {
+		return err
+	}
+	for _, e := range ts.Edges {
+		if _, err := fmt.Fprintf(edges, "%d\t%d\t%d\t%d\n", e.Left, e.Right, e.Parent, e.Child); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(sites, "position\tancestral_state"); err != nil {
+		return err
+	}
+	for _, s := range ts.Sites {
+		if _, err := fmt.Fprintf(sites, "%d\t%s\n", s.Position, stateString(s.AncestralState)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(mutations, "site\tnode\ttime\tderived_state\tparent"); err != nil {
+		return err
+	}
+	for _, m := range ts.Mutations {
+		if _, err := fmt.Fprintf(mutations, "%d\t%d\t%g\t%s\t%d\n", m.Site, m.Node, m.Time, stateString(m.DerivedState), m.Parent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package pop
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// checkTreeSequence checks the times of the tables,
+// and recovers the genomes from the mutations inherited by the samples.
+func checkTreeSequence(t *testing.T, p *Pop, ts *TreeSequence) {
+	for _, e := range ts.Edges {
+		if ts.Nodes[e.Parent].Time <= ts.Nodes[e.Child].Time {
+			t.Errorf("Expect parent %d older than child %d\n", e.Parent, e.Child)
+		}
+	}
+
+	for _, site := range ts.Sites {
+		pos := site.Position
+		parents := make(map[int]int)
+		for _, e := range ts.Edges {
+			if e.Left <= pos && pos < e.Right {
+				parents[e.Child] = e.Parent
+			}
+		}
+		latest := make(map[int]TableMutation)
+		for _, m := range ts.Mutations {
+			if ts.Sites[m.Site].Position == pos {
+				latest[m.Node] = m
+			}
+		}
+		for i := 0; i < p.Size(); i++ {
+			state := site.AncestralState
+			for n, found := i, true; found; n, found = parents[n] {
+				if m, ok := latest[n]; ok {
+					state = m.DerivedState
+					break
+				}
+			}
+			if expected := p.Genomes[i].Seq()[pos]; state != expected {
+				t.Errorf("Expect state %d of genome %d at %d, but got %d\n", expected, i, pos, state)
+			}
+		}
+	}
+}
+
+func TestTreeSequence(t *testing.T) {
+	for _, arg := range []bool{false, true} {
+		src := rand.NewSource(1)
+		r := rand.New(src)
+		p := New()
+		NewRandomPopGenerator(r, 10, 50, []byte("ACGT")).Operate(p)
+		if arg {
+			p.EnableARG()
+		}
+		p.RecordMutations = true
+		moran := NewMoranSampler(src)
+		mutator := NewSimpleMutator([]byte("ACGT"), src)
+		transfer := NewSimpleTransfer(NewConstantFrag(10), src)
+		for i := 0; i < 2000; i++ {
+			moran.Operate(p)
+			mutator.Operate(p)
+			if arg && r.Float64() < 0.5 {
+				transfer.Operate(p)
+			}
+		}
+
+		ts := NewTreeSequence(p)
+		if len(ts.Sites) == 0 {
+			t.Errorf("Expect segregating sites\n")
+		}
+		checkTreeSequence(t, p, ts)
+
+		numNodes := len(ts.Nodes)
+		numMutations := len(p.Mutations)
+		PruneLineages(p)
+		if len(p.Mutations) >= numMutations {
+			t.Errorf("Expect pruning to drop mutations on extinct lineages\n")
+		}
+		ts = NewTreeSequence(p)
+		if len(ts.Nodes) >= numNodes {
+			t.Errorf("Expect pruning to reduce %d nodes, but got %d\n", numNodes, len(ts.Nodes))
+		}
+		checkTreeSequence(t, p, ts)
+	}
+}
+
+func TestTreeSequenceText(t *testing.T) {
+	root := &Lineage{}
+	a, b := createNewLineages(root, 2)
+	p := New()
+	p.Genomes = []Genome{&NeutralGenome{Sequence: ByteSequence("AC")}, &NeutralGenome{Sequence: ByteSequence("AA")}}
+	p.Ancestor = ByteSequence("AA")
+	p.Lineages = []*Lineage{a, b}
+	p.NumGeneration = 4
+	p.Mutations = []Mutation{{Lineage: a, Pos: 1, State: 'C', Time: 3}}
+
+	var nodes, edges, sites, mutations bytes.Buffer
+	if err := NewTreeSequence(p).WriteText(&nodes, &edges, &sites, &mutations); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"id\tis_sample\ttime\n0\t1\t0\n1\t1\t0\n",
+		"left\tright\tparent\tchild\n0\t2\t2\t0\n0\t2\t3\t1\n",
+		"position\tancestral_state\n1\tA\n",
+		"site\tnode\ttime\tderived_state\tparent\n0\t2\t3\tC\t-1\n",
+	}
+	for i, s := range []string{nodes.String(), edges.String(), sites.String(), mutations.String()} {
+		if !strings.HasPrefix(s, expected[i]) {
+			t.Errorf("Expect %q, but got %q\n", expected[i], s)
+		}
+	}
+}
@@ -1,21 +1,24 @@
 package pop
 
 import (
-	"github.com/mingzhi/numgo/random"
 	"math/rand"
 	"sync"
 )
 
 // WrightFisherSampler for Wright-Fisher reproduction model.
+// Parents are chosen with the weights given by Fitness,
+// which is ExponentialMap if it is nil.
 type WrightFisherSampler struct {
-	rand *random.Rand
-	wg  sync.WaitGroup
+	Fitness FitnessMap
+
+	rw *RouletteWheel
+	wg sync.WaitGroup
 }
 
 // NewWrightFisherSampler create a new WrightFisherSampler.
 func NewWrightFisherSampler(src rand.Source) *WrightFisherSampler {
 	var w WrightFisherSampler
-	w.rand = random.New(src)
+	w.rw = NewRouletteWheel(src)
 	return &w
 }
 
@@ -29,11 +32,17 @@ func (w *WrightFisherSampler) Operate(p *Pop) {
 	currentLineages := p.Lineages
 	newGenomes := make([]Genome, p.Size())
 	newLineages := make([]*Lineage, p.Size())
+	var newAncestries []Ancestry
+	if p.Ancestries != nil {
+		newAncestries = make([]Ancestry, p.Size())
+	}
 	newGeneration := p.NumGeneration + 1
 
+	// parents are searched in the cumulative weights of the generation.
+	cum := cumulate(fitnessWeights(w.Fitness, p))
 	usedGenomes := make(map[int]bool)
 	for i := 0; i < p.Size(); i++ {
-		index := w.rand.Intn(p.Size())
+		index := w.rw.selectCumulative(cum)
 		if usedGenomes[index] {
 			newGenomes[i] = currentGenomes[index].Copy()
 		} else {
@@ -44,10 +53,14 @@ func (w *WrightFisherSampler) Operate(p *Pop) {
 		newLineages[i] = &Lineage{}
 		newLineages[i].BirthTime = newGeneration
 		newLineages[i].Parent = currentLineages[index]
+		if newAncestries != nil {
+			newAncestries[i] = p.Ancestries[index].inherit(newGeneration)
+		}
 	}
 
 	p.Genomes = newGenomes
 	p.Lineages = newLineages
+	p.Ancestries = newAncestries
 	p.NumGeneration = newGeneration
 }
 
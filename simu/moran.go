@@ -1,101 +1,372 @@
 package simu
 
 import (
+	"fmt"
+	"math"
 	"math/rand"
+	"os"
+	"sort"
 	"time"
 
 	"github.com/mingzhi/numgo/random"
 	"github.com/mingzhi/popsimu/pop"
 )
 
+// pruneInterval is the number of Moran steps between two prunings of lineages,
+// in units of the total population size.
+const pruneInterval = 100
+
 // Moran run simulations of multiple populations evolving under the Moran model.
-func Moran(pops []*pop.Pop, popConfigs []pop.Config, numGen int) {
-	randomSrc := rand.NewSource(time.Now().UnixNano())
+//
+// Populations with size schedules are resized as the time goes,
+// in generations of the total population,
+// and the rates of events are rescaled by the new sizes.
+// The populations share a clock counting the Moran steps of all of them,
+// by which births of lineages are stamped.
+// The simulation stops at the first error of an operator,
+// such as a genome it can't handle, and returns it.
+func Moran(pops []*pop.Pop, popConfigs []pop.Config, numGen int) error {
+	src := rand.NewSource(time.Now().UnixNano())
+	_, err := evolveMoran(pops, popConfigs, numGen, 0, math.Inf(1), src)
+	return err
+}
+
+// RunScenario runs Moran simulations of the populations,
+// applying the demographic events at their times in generations until the end,
+// and returns the populations with their configs at the end,
+// and the records of the events,
+// or the first error of the operators and the events.
+// Runs are reproducible from the random source.
+func RunScenario(pops []*pop.Pop, popConfigs []pop.Config, events []pop.DemographicEvent, end float64, src rand.Source) ([]*pop.Pop, []pop.Config, []pop.DemographicRecord, error) {
+	events = append([]pop.DemographicEvent{}, events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time < events[j].Time })
+
+	records := []pop.DemographicRecord{}
+	t := 0.0
+	for _, e := range events {
+		if e.Time > end {
+			break
+		}
+		var err error
+		if e.Time > t {
+			t, err = evolveMoran(pops, popConfigs, math.MaxInt64, t, e.Time, src)
+			if err != nil {
+				return pops, popConfigs, records, err
+			}
+		}
+		pops, popConfigs, err = e.Apply(pops, popConfigs, src)
+		if err != nil {
+			return pops, popConfigs, records, err
+		}
+		r := pop.DemographicRecord{Time: t, Event: e}
+		for _, p := range pops {
+			r.Sizes = append(r.Sizes, p.Size())
+		}
+		records = append(records, r)
+	}
+	if end > t {
+		if _, err := evolveMoran(pops, popConfigs, math.MaxInt64, t, end, src); err != nil {
+			return pops, popConfigs, records, err
+		}
+	}
+	return pops, popConfigs, records, nil
+}
+
+// evolveMoran runs at most numGen Moran steps from the time start until the time end,
+// in generations, and returns the time reached,
+// or the first error of the operators.
+func evolveMoran(pops []*pop.Pop, popConfigs []pop.Config, numGen int, start, end float64, randomSrc rand.Source) (float64, error) {
+	// events are emitted in another goroutine than the operators,
+	// so that they draw from a source of their own,
+	// seeded by the given one.
+	emitSrc := rand.NewSource(rand.New(randomSrc).Int63())
+
+	for i := 0; i < len(pops); i++ {
+		if popConfigs[i].ARG && pops[i].Ancestries == nil {
+			pops[i].EnableARG()
+		}
+	}
+	// the populations share a clock,
+	// so that lineages across them are on the same time scale.
+	pop.SyncClocks(pops...)
+
+	// schedules start from the sizes in the configs,
+	// and run on the time from the start of the simulation.
+	schedules := make([]pop.SizeSchedule, len(pops))
+	for i := 0; i < len(pops); i++ {
+		initial := popConfigs[i].Size
+		if initial <= 0 {
+			initial = pops[i].Size()
+		}
+		s, err := pop.NewSizeSchedule(initial, popConfigs[i].Schedule)
+		if err != nil {
+			return start, err
+		}
+		schedules[i] = s
+	}
 
 	// Prepare a collection of possible events.
-	events := generateEvents(popConfigs, pops, randomSrc)
-	moranEvents := generateMoranEvents(popConfigs, pops, randomSrc)
+	events, err := generateEvents(popConfigs, pops, randomSrc)
+	if err != nil {
+		return start, err
+	}
+	moranEvents, err := generateMoranEvents(popConfigs, pops, randomSrc)
+	if err != nil {
+		return start, err
+	}
 
-	totalPopSize := 0
+	// sizes of the populations are tracked here,
+	// since the populations are changed by another goroutine.
+	sizes := make(map[*pop.Pop]int)
 	for i := 0; i < len(pops); i++ {
-		totalPopSize += pops[i].Size()
+		sizes[pops[i]] = pops[i].Size()
 	}
+	rates := newEventRates(append(append([]*pop.Event{}, events...), moranEvents...), sizes)
 
-	// total rate of all events scale by the total population size.
-	totalRate := 0.0
-	for i := 0; i < len(events); i++ {
-		// the rate unit is per genome per generation,
-		// so we need to rescale it by dividing the population size.
-		totalRate += events[i].Rate / float64(totalPopSize)
+	// lineages of all the populations are pruned together,
+	// since they might share ancestors.
+	pruneEvent := &pop.Event{
+		Ops: pop.NewLineagePruner(pops...),
+		Pop: pops[0],
 	}
 
-	r := random.New(randomSrc)
-	rw := pop.NewRouletteWheel(randomSrc)
+	r := random.New(emitSrc)
+	rw := pop.NewRouletteWheel(emitSrc)
 	eventChan := make(chan *pop.Event)
+	// done stops the events after an error.
+	done := make(chan struct{})
+	send := func(e *pop.Event) bool {
+		select {
+		case eventChan <- e:
+			return true
+		case <-done:
+			return false
+		}
+	}
+	t := start
 	go func() {
 		defer close(eventChan)
-		for i := 0; i < numGen; i++ {
-			e := pop.Emit(moranEvents, rw)
-			eventChan <- e
+		totalPopSize, totalRate := totalRates(events, sizes)
+		sincePrune := 0
+		for i := 0; i < numGen && t < end; i++ {
+			resized := false
+			for j := 0; j < len(pops); j++ {
+				if schedules[j] == nil {
+					continue
+				}
+				if n := schedules[j].Size(t); n != sizes[pops[j]] {
+					sizes[pops[j]] = n
+					if !send(&pop.Event{Ops: pop.NewResizer(n, randomSrc), Pop: pops[j]}) {
+						return
+					}
+					resized = true
+				}
+			}
+			if resized {
+				rates.rescale(sizes)
+				totalPopSize, totalRate = totalRates(events, sizes)
+			}
+
+			if sincePrune >= pruneInterval*totalPopSize {
+				if !send(pruneEvent) {
+					return
+				}
+				sincePrune = 0
+			}
+			sincePrune++
+
+			if !send(pop.Emit(moranEvents, rw)) {
+				return
+			}
 			eventCount := r.PoissonInt64(totalRate)
 			var i int64
 			for ; i < eventCount; i++ {
-				e := pop.Emit(events, rw)
-				eventChan <- e
+				if !send(pop.Emit(events, rw)) {
+					return
+				}
 			}
+			t += 1 / float64(totalPopSize)
 		}
 	}()
 
-	pop.Evolve(eventChan)
+	err = pop.EvolveApply(eventChan)
+	close(done)
+	// wait for the events to stop.
+	for range eventChan {
+	}
+	return t, err
+}
+
+// totalRates returns the total population size,
+// and the total rate of the events per Moran step.
+func totalRates(events []*pop.Event, sizes map[*pop.Pop]int) (totalPopSize int, totalRate float64) {
+	for _, n := range sizes {
+		totalPopSize += n
+	}
+	// total rate of all events scale by the total population size.
+	for i := 0; i < len(events); i++ {
+		// the rate unit is per genome per generation,
+		// so we need to rescale it by dividing the population size.
+		totalRate += events[i].Rate / float64(totalPopSize)
+	}
+	return
+}
+
+// eventRates rescales the rates of events as the population sizes change.
+type eventRates struct {
+	events []*pop.Event
+	base   []float64 // rates per unit of eventScale.
+}
+
+func newEventRates(events []*pop.Event, sizes map[*pop.Pop]int) *eventRates {
+	r := &eventRates{events: events}
+	for _, e := range events {
+		r.base = append(r.base, e.Rate/eventScale(e, sizes))
+	}
+	return r
+}
+
+func (r *eventRates) rescale(sizes map[*pop.Pop]int) {
+	for i, e := range r.events {
+		e.Rate = r.base[i] * eventScale(e, sizes)
+	}
+}
+
+// eventScale returns the factor of the population sizes in the rate of an event,
+// which is the size of its population,
+// times the share of the donor population among the others for transfers between populations.
+func eventScale(e *pop.Event, sizes map[*pop.Pop]int) float64 {
+	scale := float64(sizes[e.Pop])
+	var donor *pop.Pop
+	switch o := e.Ops.(type) {
+	case *pop.OutTransfer:
+		donor = o.DonorPop
+	case *pop.InsertionTransfer:
+		donor = o.DonorPop
+	}
+	if donor != nil {
+		others := 0
+		for p, n := range sizes {
+			if p != e.Pop {
+				others += n
+			}
+		}
+		scale *= float64(sizes[donor]) / float64(others)
+	}
+	return scale
 }
 
-func generateEvents(popConfigs []pop.Config, pops []*pop.Pop, src rand.Source) (events []*pop.Event) {
+// generateEvents returns the events of the populations other than Moran steps,
+// or an error of the configs.
+func generateEvents(popConfigs []pop.Config, pops []*pop.Pop, src rand.Source) (events []*pop.Event, err error) {
+	siteRates := make(map[siteRatesKey]*pop.SiteRates)
 	for i := 0; i < len(popConfigs); i++ {
 		c := popConfigs[i]
 		p := pops[i]
 
+		sites, err := generateSiteRates(c, siteRates, src)
+		if err != nil {
+			return nil, err
+		}
+		mutator := pop.NewSimpleMutator([]byte(c.Alphabet), src)
+		mutator.Sites = sites
 		mutateEvent := &pop.Event{
 			Rate: c.Mutation.Rate * float64(p.Size()*c.Length),
-			Ops:  pop.NewSimpleMutator([]byte(c.Alphabet), src),
+			Ops:  mutator,
 			Pop:  pops[i],
 		}
+		if sites != nil {
+			mutateEvent.Rate *= sites.Mean()
+		}
+		if m := c.Mutation.Model; m.Name != "" {
+			model, err := pop.NewSubstitutionModel(m.Name, []byte(c.Alphabet), m.Kappa, m.Exchanges, m.Freqs)
+			if err != nil {
+				return nil, err
+			}
+			// substitutions are rejected at the rate MaxRate - Rate(i).
+			mutateEvent.Rate *= model.MaxRate()
+			modelMutator := pop.NewModelMutator(model, src)
+			modelMutator.Sites = sites
+			mutateEvent.Ops = modelMutator
+		}
 		events = append(events, mutateEvent)
 
+		if d := c.Mutation.DFE; d.Rate > 0 {
+			dfe, err := pop.NewDFE(d.Neutral, d.Deleterious, d.Beneficial, d.Shape, d.MeanDeleterious, d.MeanBeneficial, src)
+			if err != nil {
+				return nil, err
+			}
+			dfeEvent := &pop.Event{
+				Rate: d.Rate * float64(p.Size()*c.Length),
+				Ops:  pop.NewDFEMutator(dfe, src),
+				Pop:  pops[i],
+			}
+			events = append(events, dfeEvent)
+		}
+
 		// choosing fragment size generator.
-		var inFragGenerator pop.FragSizeGenerator
-		switch c.FragGenerator {
-		case "exponential":
-			lambda := 1.0 / float64(c.Transfer.In.Fragment)
-			inFragGenerator = pop.NewExpFrag(lambda, src)
-		default:
-			inFragGenerator = pop.NewConstantFrag(c.Transfer.In.Fragment)
+		inFragGenerator, err := generateFrag(c, c.Transfer.In.Fragment, src)
+		if err != nil {
+			return nil, err
 		}
 
+		recombMap, err := generateRecombinationMap(c)
+		if err != nil {
+			return nil, err
+		}
+		inTransfer := pop.NewSimpleTransfer(inFragGenerator, src)
+		inTransfer.Delta = c.Transfer.In.Delta
+		inTransfer.Seed = c.Transfer.In.Seed
+		inTransfer.Map = recombMap
 		inTransferEvent := &pop.Event{
 			Rate: c.Transfer.In.Rate * float64(p.Size()*c.Length),
-			Ops:  pop.NewSimpleTransfer(inFragGenerator, src),
+			Ops:  inTransfer,
 			Pop:  pops[i],
 		}
 		events = append(events, inTransferEvent)
 
-		// choosing fragment size generator.
-		var outFragGenerator pop.FragSizeGenerator
-		switch c.FragGenerator {
-		case "exponential":
-			lambda := 1.0 / float64(c.Transfer.Out.Fragment)
-			outFragGenerator = pop.NewExpFrag(lambda, src)
-		default:
-			outFragGenerator = pop.NewConstantFrag(c.Transfer.In.Fragment)
+		// choosing fragment size generator,
+		// in which out transfers take the size of in transfers if unset.
+		outFragment := c.Transfer.Out.Fragment
+		if outFragment == 0 {
+			outFragment = c.Transfer.In.Fragment
+		}
+		outFragGenerator, err := generateFrag(c, outFragment, src)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.Indel.Rate > 0 {
+			indelFragGenerator, err := generateFrag(c, c.Indel.Fragment, src)
+			if err != nil {
+				return nil, err
+			}
+			indelEvent := &pop.Event{
+				Rate: c.Indel.Rate * float64(p.Size()*c.Length),
+				Ops:  pop.NewIndel([]byte(c.Alphabet), indelFragGenerator, c.Indel.Insertion, src),
+				Pop:  pops[i],
+			}
+			events = append(events, indelEvent)
+		}
+
+		insertionEvents, err := generateInsertionEvents(c, i, pops, src)
+		if err != nil {
+			return nil, err
 		}
+		events = append(events, insertionEvents...)
 
 		outTransferEvents := []*pop.Event{}
 		totalSize := 0
 		for j := 0; j < len(popConfigs); j++ {
 			pj := pops[j]
 			if i != j {
+				outTransfer := pop.NewOutTransfer(outFragGenerator, pj, src)
+				outTransfer.Delta = c.Transfer.Out.Delta
+				outTransfer.Seed = c.Transfer.Out.Seed
+				outTransfer.Map = recombMap
 				outE := &pop.Event{
 					Rate: c.Transfer.Out.Rate * float64(p.Size()*c.Length*pj.Size()),
-					Ops:  pop.NewOutTransfer(outFragGenerator, pj, src),
+					Ops:  outTransfer,
 					Pop:  pops[i],
 				}
 				totalSize += pj.Size()
@@ -110,18 +381,196 @@ func generateEvents(popConfigs []pop.Config, pops []*pop.Pop, src rand.Source) (
 		}
 	}
 
+	migrationEvents, err := generateMigrationEvents(popConfigs, pops, src)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, migrationEvents...)
+
+	return
+}
+
+// generateMigrationEvents returns the events of migration between the populations,
+// whose rates are given by the migration matrix of the configs.
+func generateMigrationEvents(popConfigs []pop.Config, pops []*pop.Pop, src rand.Source) (events []*pop.Event, err error) {
+	if len(pops) < 2 {
+		return
+	}
+	rows := [][]float64{}
+	for i := 0; i < len(popConfigs); i++ {
+		m := popConfigs[i].Migration
+		switch m.Topology {
+		case "", "island":
+			rows = append(rows, pop.IslandMigration(len(pops), m.Rate)[i])
+		case "stepping-stone":
+			rows = append(rows, pop.SteppingStoneMigration(len(pops), m.Rate, false)[i])
+		case "ring":
+			rows = append(rows, pop.SteppingStoneMigration(len(pops), m.Rate, true)[i])
+		case "matrix":
+			rows = append(rows, m.Matrix)
+		default:
+			return nil, fmt.Errorf("unknown migration topology %s", m.Topology)
+		}
+	}
+	matrix, err := pop.NewMigrationMatrix(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(pops); i++ {
+		for j := 0; j < len(pops); j++ {
+			if matrix[i][j] > 0 {
+				e := &pop.Event{
+					Rate: matrix[i][j] * float64(pops[i].Size()),
+					Ops:  pop.NewMigration(pops[j], src),
+					Pop:  pops[i],
+				}
+				events = append(events, e)
+			}
+		}
+	}
+	return
+}
+
+// generateFrag returns the fragment size generator of the config,
+// with the mean size.
+func generateFrag(c pop.Config, mean int, src rand.Source) (pop.FragSizeGenerator, error) {
+	return pop.NewFragSizeGenerator(c.FragGenerator, mean, c.FragParams, src)
+}
+
+// generateRecombinationMap returns the recombination map of the config,
+// from the map file, or from the inline regions,
+// or nil if there is neither.
+func generateRecombinationMap(c pop.Config) (*pop.RecombinationMap, error) {
+	if c.Transfer.MapFile != "" {
+		f, err := os.Open(c.Transfer.MapFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return pop.ReadRecombinationMap(f, c.Length)
+	}
+	if len(c.Transfer.Map) > 0 {
+		return pop.NewRecombinationMap(c.Length, c.Transfer.Map)
+	}
+	return nil, nil
+}
+
+// generateInsertionEvents returns the events of non-homologous transfers
+// into the i-th population, from the donor pool of the config,
+// or from the other populations in proportion to their sizes.
+func generateInsertionEvents(c pop.Config, i int, pops []*pop.Pop, src rand.Source) (events []*pop.Event, err error) {
+	ins := c.Transfer.Insertion
+	if ins.Rate <= 0 {
+		return
+	}
+
+	fragGenerator, err := generateFrag(c, ins.Fragment, src)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := ins.Rate * float64(pops[i].Size()*c.Length)
+	if ins.Pool != "" {
+		f, err := os.Open(ins.Pool)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		pool, err := pop.ReadDonorPool(f)
+		if err != nil {
+			return nil, err
+		}
+		e := &pop.Event{
+			Rate: rate,
+			Ops:  pop.NewPoolInsertionTransfer(fragGenerator, pool, src),
+			Pop:  pops[i],
+		}
+		return append(events, e), nil
+	}
+
+	totalSize := 0
+	for j := 0; j < len(pops); j++ {
+		if i != j {
+			totalSize += pops[j].Size()
+		}
+	}
+	for j := 0; j < len(pops); j++ {
+		if i != j {
+			e := &pop.Event{
+				Rate: rate * float64(pops[j].Size()) / float64(totalSize),
+				Ops:  pop.NewInsertionTransfer(fragGenerator, pops[j], src),
+				Pop:  pops[i],
+			}
+			events = append(events, e)
+		}
+	}
 	return
 }
 
-func generateMoranEvents(popConfigs []pop.Config, pops []*pop.Pop, src rand.Source) (moranEvents []*pop.Event) {
+// siteRatesKey identifies the parameters of site rates.
+type siteRatesKey struct {
+	length     int
+	shape      float64
+	categories int
+	invariant  float64
+}
+
+// generateSiteRates returns the site rates of the config,
+// or nil if the sites have equal rates.
+// Populations of the same parameters share the same site rates,
+// which are fixed for the whole run.
+func generateSiteRates(c pop.Config, cache map[siteRatesKey]*pop.SiteRates, src rand.Source) (*pop.SiteRates, error) {
+	g := c.Mutation.Gamma
+	if g.Shape == 0 && g.Invariant == 0 {
+		return nil, nil
+	}
+	categories := g.Categories
+	if categories == 0 {
+		categories = 4
+	}
+	key := siteRatesKey{length: c.Length, shape: g.Shape, categories: categories, invariant: g.Invariant}
+	if sites, found := cache[key]; found {
+		return sites, nil
+	}
+	sites, err := pop.NewGammaSiteRates(c.Length, g.Shape, categories, g.Invariant, src)
+	if err != nil {
+		return nil, err
+	}
+	cache[key] = sites
+	return sites, nil
+}
+
+func generateMoranEvents(popConfigs []pop.Config, pops []*pop.Pop, src rand.Source) (moranEvents []*pop.Event, err error) {
 	r := rand.New(src)
 	for i := 0; i < len(popConfigs); i++ {
+		c := popConfigs[i]
+		fitness, err := pop.NewFitnessMap(c.FitnessMap, c.TruncationFraction)
+		if err != nil {
+			return nil, err
+		}
+		sampler := pop.NewMoranSampler(r)
+		sampler.Fitness = fitness
 		event := &pop.Event{
 			Rate: float64(pops[i].Size()),
-			Ops:  pop.NewMoranSampler(r),
+			Ops:  &clockedOperator{Operator: sampler, pops: pops},
 			Pop:  pops[i],
 		}
 		moranEvents = append(moranEvents, event)
 	}
 	return
 }
+
+// clockedOperator operates on a population,
+// and then synchronizes the clocks of all the populations,
+// so that births in any of them are stamped
+// by the number of Moran steps of the whole simulation.
+type clockedOperator struct {
+	pop.Operator
+	pops []*pop.Pop
+}
+
+func (c *clockedOperator) Operate(p *pop.Pop) {
+	c.Operator.Operate(p)
+	pop.SyncClocks(c.pops...)
+}
@@ -0,0 +1,59 @@
+package simu
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mingzhi/popsimu/pop"
+)
+
+func TestMoranSchedule(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	p := pop.New()
+	pop.NewRandomPopGenerator(r, 20, 20, []byte("ACGT")).Operate(p)
+	c := pop.Config{Size: 20, Length: 20, Alphabet: "ACGT"}
+	c.Mutation.Rate = 0.01
+	c.Schedule = pop.ScheduleParams{Name: "step", Times: []float64{2, 4}, Sizes: []int{40, 10}}
+
+	pops, _, _, err := RunScenario([]*pop.Pop{p}, []pop.Config{c}, nil, 3, rand.NewSource(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pops[0].Size() != 40 {
+		t.Errorf("Expect 40 genomes after the first step, but got %d\n", pops[0].Size())
+	}
+
+	pops, _, _, err = RunScenario(pops, []pop.Config{c}, nil, 6, rand.NewSource(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pops[0].Size() != 10 {
+		t.Errorf("Expect 10 genomes after the second step, but got %d\n", pops[0].Size())
+	}
+}
+
+func TestMoranConfigErrors(t *testing.T) {
+	newPop := func() *pop.Pop {
+		p := pop.New()
+		pop.NewRandomPopGenerator(rand.New(rand.NewSource(1)), 10, 20, []byte("ACGT")).Operate(p)
+		return p
+	}
+
+	c := pop.Config{Size: 10, Length: 20, Alphabet: "ACGT"}
+	c.Schedule.Name = "sawtooth"
+	if err := Moran([]*pop.Pop{newPop()}, []pop.Config{c}, 10); err == nil {
+		t.Error("Expect an error of an unknown schedule\n")
+	}
+
+	c = pop.Config{Size: 10, Length: 20, Alphabet: "ACGT"}
+	c.Migration.Rate = 0.1
+	c.Migration.Topology = "torus"
+	if err := Moran([]*pop.Pop{newPop(), newPop()}, []pop.Config{c, c}, 10); err == nil {
+		t.Error("Expect an error of an unknown migration topology\n")
+	}
+
+	c = pop.Config{Size: 10, Length: 20, Alphabet: "ACGT", FitnessMap: "quadratic"}
+	if err := Moran([]*pop.Pop{newPop()}, []pop.Config{c}, 10); err == nil {
+		t.Error("Expect an error of an unknown fitness map\n")
+	}
+}
@@ -0,0 +1,84 @@
+package simu
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mingzhi/popsimu/pop"
+)
+
+func TestRunScenario(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	p := pop.New()
+	pop.NewRandomPopGenerator(r, 30, 20, []byte("ACGT")).Operate(p)
+	c := pop.Config{Size: 30, Length: 20, Alphabet: "ACGT", ARG: true}
+	c.Mutation.Rate = 0.01
+	events := []pop.DemographicEvent{
+		{Time: 20, Type: pop.MergeEvent, Pops: []int{0, 1}},
+		{Time: 5, Type: pop.SplitEvent, Pops: []int{0}, Sizes: []int{20, 10}},
+	}
+
+	pops, configs, records, err := RunScenario([]*pop.Pop{p}, []pop.Config{c}, events, 30, rand.NewSource(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Event.Type != pop.SplitEvent || records[1].Event.Type != pop.MergeEvent {
+		t.Fatalf("Expect a split and a merge in order, but got %v\n", records)
+	}
+	if len(records[0].Sizes) != 2 || records[0].Sizes[0] != 20 || records[0].Sizes[1] != 10 {
+		t.Errorf("Expect daughters of sizes 20 and 10, but got %v\n", records[0].Sizes)
+	}
+	if len(pops) != 1 || len(configs) != 1 || pops[0].Size() != 30 {
+		t.Fatalf("Expect a merged population of 30 genomes\n")
+	}
+
+	// lineages are born by a common clock across the split and the merge.
+	m := pops[0]
+	check := func(l *pop.Lineage) {
+		for ; l != nil && l.Parent != nil; l = l.Parent {
+			if l.Parent.BirthTime > l.BirthTime || l.BirthTime > m.NumGeneration {
+				t.Fatalf("Expect a parent born at %d before its child born at %d\n", l.Parent.BirthTime, l.BirthTime)
+			}
+		}
+	}
+	for i, l := range m.Lineages {
+		check(l)
+		for _, s := range m.Ancestries[i] {
+			check(s.Lineage)
+		}
+	}
+	// the size is constant, so that the clock counts 30 steps per generation.
+	if m.NumGeneration < 30*29 || m.NumGeneration > 30*31 {
+		t.Errorf("Expect about %d Moran steps, but got %d\n", 30*30, m.NumGeneration)
+	}
+}
+
+func TestRunScenarioSeed(t *testing.T) {
+	run := func() *pop.Pop {
+		p := pop.New()
+		pop.NewRandomPopGenerator(rand.New(rand.NewSource(1)), 30, 20, []byte("ACGT")).Operate(p)
+		c := pop.Config{Size: 30, Length: 20, Alphabet: "ACGT"}
+		c.Mutation.Rate = 0.1
+		c.Transfer.In.Rate = 0.1
+		c.Transfer.In.Fragment = 5
+		events := []pop.DemographicEvent{
+			{Time: 2, Type: pop.SplitEvent, Pops: []int{0}, Sizes: []int{20, 10}},
+			{Time: 5, Type: pop.MergeEvent, Pops: []int{0, 1}},
+		}
+		pops, _, _, err := RunScenario([]*pop.Pop{p}, []pop.Config{c}, events, 8, rand.NewSource(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pops[0]
+	}
+
+	p1, p2 := run(), run()
+	if p1.Size() != p2.Size() || p1.NumGeneration != p2.NumGeneration {
+		t.Fatalf("Expect the same population with the same seed, but got %d and %d genomes\n", p1.Size(), p2.Size())
+	}
+	for i := range p1.Genomes {
+		if string(p1.Genomes[i].Seq()) != string(p2.Genomes[i].Seq()) || p1.Lineages[i].BirthTime != p2.Lineages[i].BirthTime {
+			t.Fatalf("Expect the same genome %d with the same seed\n", i)
+		}
+	}
+}
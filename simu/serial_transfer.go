@@ -0,0 +1,143 @@
+package simu
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/mingzhi/numgo/random"
+	"github.com/mingzhi/popsimu/pop"
+)
+
+// PassageRecord stores the statistics of a population after a transfer.
+type PassageRecord struct {
+	Transfer    int
+	Generations float64 // number of generations since the start.
+	Survivors   int     // size after the shock, or before the dilution without it.
+	Bottleneck  int     // size after the dilution.
+	Size        int     // size after the growth.
+	Ks          float64
+	Vd          float64
+	MeanFit     float64
+	MaxFit      float64
+	TajimaD     float64
+}
+
+// SerialTransfer runs the serial-transfer protocol of the config on the population,
+// drawing random numbers from the source,
+// and returns the statistics after each transfer.
+//
+// In each transfer, the population is diluted by the dilution factor,
+// and grows back to the carrying capacity,
+// in which genomes divide with the weights given by the fitness map of the config,
+// and mutations and transfers happen at their rates per division.
+// The shock of the protocol is applied before the dilution in its transfers,
+// and the protocol stops if the population goes extinct,
+// or at the first error of an operator, which is returned with the records before it.
+func SerialTransfer(p *pop.Pop, c pop.Config, src rand.Source) ([]PassageRecord, error) {
+	s := c.SerialTransfer
+	if s.Dilution <= 0 || s.Dilution > 1 {
+		return nil, fmt.Errorf("serial transfer requires a dilution factor in (0, 1], got %g", s.Dilution)
+	}
+	capacity := s.Capacity
+	if capacity <= 0 {
+		capacity = p.Size()
+	}
+	if capacity < p.Size() {
+		return nil, fmt.Errorf("serial transfer requires a capacity of at least the population size %d, got %d", p.Size(), capacity)
+	}
+	sampleSize := s.SampleSize
+	if sampleSize <= 0 || sampleSize > capacity {
+		sampleSize = capacity
+	}
+
+	if c.ARG && p.Ancestries == nil {
+		p.EnableARG()
+	}
+	fitness, err := pop.NewFitnessMap(c.FitnessMap, c.TruncationFraction)
+	if err != nil {
+		return nil, err
+	}
+	growth := pop.NewGrowth(fitness, src)
+	dilution := pop.NewDilution(s.Dilution, src)
+	shock, err := pop.NewShock(s.Shock, fitness, src)
+	if err != nil {
+		return nil, err
+	}
+	shocked := make(map[int]bool)
+	for _, t := range s.ShockAt {
+		shocked[t] = true
+	}
+
+	// the rates of events are per genome per generation,
+	// so they are rescaled as the population grows,
+	// and their total rate per division by the population size.
+	events, err := generateEvents([]pop.Config{c}, []*pop.Pop{p}, src)
+	if err != nil {
+		return nil, err
+	}
+	sizes := map[*pop.Pop]int{p: p.Size()}
+	rates := newEventRates(events, sizes)
+	r := random.New(src)
+	rw := pop.NewRouletteWheel(src)
+	divided := func(*pop.Pop) error {
+		sizes[p] = p.Size()
+		rates.rescale(sizes)
+		_, totalRate := totalRates(events, sizes)
+		n := r.PoissonInt64(totalRate)
+		for i := int64(0); i < n; i++ {
+			e := pop.Emit(events, rw)
+			if err := pop.Apply(e.Ops, e.Pop); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	records := []PassageRecord{}
+	generations := 0.0
+	for t := 1; t <= s.Transfers; t++ {
+		numGeneration := p.NumGeneration
+		survived := p
+		if shock != nil && shocked[t] {
+			survived = shock.Reduce(p)
+		}
+		survivors := survived.Size()
+		diluted := dilution.Reduce(survived)
+		// lineages keep counting the generations through the dilution.
+		diluted.NumGeneration = numGeneration
+		bottleneck := diluted.Size()
+		// the population is changed in place, on which the events operate.
+		*p = *diluted
+		if bottleneck == 0 {
+			// the population goes extinct.
+			records = append(records, PassageRecord{
+				Transfer:    t,
+				Generations: generations,
+				Survivors:   survivors,
+			})
+			break
+		}
+		if _, err := growth.Grow(p, capacity, divided); err != nil {
+			return records, err
+		}
+		pop.PruneLineages(p)
+		generations += math.Log2(float64(capacity) / float64(bottleneck))
+
+		ks, vd := pop.CalcKs(sampleSize, src, p)
+		neutrality := pop.CalcNeutrality(sampleSize, src, p)
+		records = append(records, PassageRecord{
+			Transfer:    t,
+			Generations: generations,
+			Survivors:   survivors,
+			Bottleneck:  bottleneck,
+			Size:        p.Size(),
+			Ks:          ks,
+			Vd:          vd,
+			MeanFit:     p.MeanFit(),
+			MaxFit:      p.MaxFit(),
+			TajimaD:     neutrality.TajimaD,
+		})
+	}
+	return records, nil
+}
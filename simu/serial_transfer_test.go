@@ -0,0 +1,136 @@
+package simu
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/mingzhi/popsimu/pop"
+)
+
+func serialTransferConfig() pop.Config {
+	c := pop.Config{Size: 40, Length: 50, Alphabet: "ACGT"}
+	c.Mutation.Rate = 0.5
+	c.Transfer.In.Rate = 0.2
+	c.Transfer.In.Fragment = 10
+	c.SerialTransfer.Dilution = 0.25
+	c.SerialTransfer.Capacity = 40
+	c.SerialTransfer.Transfers = 5
+	return c
+}
+
+func serialTransferPop(c pop.Config) *pop.Pop {
+	p := pop.New()
+	r := rand.New(rand.NewSource(1))
+	pop.NewRandomPopGenerator(r, c.Size, c.Length, []byte(c.Alphabet)).Operate(p)
+	return p
+}
+
+func TestSerialTransferSizes(t *testing.T) {
+	c := serialTransferConfig()
+	p := serialTransferPop(c)
+	records, err := SerialTransfer(p, c, rand.NewSource(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != c.SerialTransfer.Transfers {
+		t.Fatalf("Expect %d transfers, but got %d\n", c.SerialTransfer.Transfers, len(records))
+	}
+	for i, r := range records {
+		if r.Transfer != i+1 {
+			t.Errorf("Expect transfer %d, but got %d\n", i+1, r.Transfer)
+		}
+		if r.Survivors != 40 || r.Bottleneck != 10 || r.Size != 40 {
+			t.Errorf("Expect 40 -> 10 -> 40 genomes in transfer %d, but got %d -> %d -> %d\n", r.Transfer, r.Survivors, r.Bottleneck, r.Size)
+		}
+		if r.Generations != float64(2*(i+1)) {
+			t.Errorf("Expect %d generations after transfer %d, but got %f\n", 2*(i+1), r.Transfer, r.Generations)
+		}
+	}
+	if p.Size() != 40 {
+		t.Errorf("Expect the population of 40 genomes, but got %d\n", p.Size())
+	}
+}
+
+func TestSerialTransferSeed(t *testing.T) {
+	c := serialTransferConfig()
+	p1 := serialTransferPop(c)
+	records1, err := SerialTransfer(p1, c, rand.NewSource(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2 := serialTransferPop(c)
+	records2, err := SerialTransfer(p2, c, rand.NewSource(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records1) != len(records2) {
+		t.Fatalf("Expect the same number of records, but got %d and %d\n", len(records1), len(records2))
+	}
+	for i := range records1 {
+		r1, r2 := records1[i], records2[i]
+		if r1.Bottleneck != r2.Bottleneck || r1.Size != r2.Size || r1.Ks != r2.Ks || r1.MeanFit != r2.MeanFit {
+			t.Errorf("Expect the same record of transfer %d, but got %v and %v\n", r1.Transfer, r1, r2)
+		}
+	}
+	for i := range p1.Genomes {
+		if !bytes.Equal(p1.Genomes[i].Seq(), p2.Genomes[i].Seq()) {
+			t.Fatalf("Expect the same genome %d with the same seed\n", i)
+		}
+	}
+}
+
+func TestSerialTransferErrors(t *testing.T) {
+	c := serialTransferConfig()
+	c.SerialTransfer.Dilution = 0
+	if _, err := SerialTransfer(serialTransferPop(c), c, rand.NewSource(1)); err == nil {
+		t.Error("Expect an error of a zero dilution\n")
+	}
+
+	c = serialTransferConfig()
+	c.SerialTransfer.Shock.Name = "flood"
+	if _, err := SerialTransfer(serialTransferPop(c), c, rand.NewSource(1)); err == nil {
+		t.Error("Expect an error of an unknown shock\n")
+	}
+
+	c = serialTransferConfig()
+	c.SerialTransfer.Capacity = 20
+	if _, err := SerialTransfer(serialTransferPop(c), c, rand.NewSource(1)); err == nil {
+		t.Error("Expect an error of a capacity below the population size\n")
+	}
+
+	c = serialTransferConfig()
+	c.FragGenerator = "geometric"
+	c.Transfer.In.Fragment = 0
+	if _, err := SerialTransfer(serialTransferPop(c), c, rand.NewSource(1)); err == nil {
+		t.Error("Expect an error of a geometric fragment without a mean\n")
+	}
+}
+
+// plainGenome is a genome without indels.
+type plainGenome struct {
+	pop.Genome
+}
+
+func (g plainGenome) Copy() pop.Genome {
+	return plainGenome{g.Genome.Copy()}
+}
+
+func TestSerialTransferOperatorError(t *testing.T) {
+	c := serialTransferConfig()
+	c.Indel.Rate = 1
+	c.Indel.Fragment = 1
+	p := serialTransferPop(c)
+	for i, g := range p.Genomes {
+		p.Genomes[i] = plainGenome{g}
+	}
+
+	records, err := SerialTransfer(p, c, rand.NewSource(1))
+	if _, ok := err.(*pop.UnsupportedGenomeError); !ok {
+		t.Fatalf("Expect an UnsupportedGenomeError, but got %v\n", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("Expect no record before the error, but got %d\n", len(records))
+	}
+}